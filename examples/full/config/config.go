@@ -27,22 +27,20 @@ func GetDefaultConfig(format string) string {
 	}
 }
 
-// Carica la configurazione da file.
+// Carica la configurazione da file, con eventuale override da systemd (per qualsiasi formato)
+// e da variabili d'ambiente MYSETTINGS_*.
 func Load(filename string) error {
-	loadedFilename, err := settings.LoadFile(filename, Cfg, true)
-	if err != nil {
-		return err
-	}
-	fmt.Println("settings loaded from: " + loadedFilename)
+	loader := settings.NewLoader(
+		settings.FileSource(filename, true),
+		settings.SystemdCredentialsSource(filepath.Base(filename), false),
+		settings.EnvSource("MYSETTINGS"),
+	)
 
-	// Carica la configurazione da systemd, per qualsiasi formato.
-	systemdFilename := filepath.Base(filename)
-	loadedFilename, err = settings.LoadSystemdCredentials(systemdFilename, Cfg, false)
-	if err != nil {
-		return err
-	}
-	if loadedFilename != "" {
-		fmt.Println("systemd settings loaded from: " + loadedFilename)
+	results, err := loader.Load(Cfg)
+	for _, result := range results {
+		if result.Status == settings.SourceLoaded {
+			fmt.Println("settings loaded from: " + result.Origin)
+		}
 	}
 
 	return err
@@ -32,6 +32,23 @@ func TestGenerator(t *testing.T) {
 		{"../testdata", "Simple", "../testdata/simple_all_fields.jsonc", renderers.AllFields},
 		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.jsonc", renderers.AllFields},
 
+		// json5
+		{"../testdata", "Embedding", "../testdata/embedding.json5", renderers.NoFields},
+		{"../testdata", "Empty", "../testdata/empty.json5", renderers.NoFields},
+		{"../testdata", "Nesting", "../testdata/nesting.json5", renderers.NoFields},
+		{"../testdata", "Simple", "../testdata/simple.json5", renderers.NoFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package.json5", renderers.NoFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_basic_fields.json5", renderers.BasicFields},
+		{"../testdata", "Nesting", "../testdata/nesting_basic_fields.json5", renderers.BasicFields},
+		{"../testdata", "Simple", "../testdata/simple_basic_fields.json5", renderers.BasicFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_basic_fields.json5", renderers.BasicFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_all_fields.json5", renderers.AllFields},
+		{"../testdata", "Nesting", "../testdata/nesting_all_fields.json5", renderers.AllFields},
+		{"../testdata", "Simple", "../testdata/simple_all_fields.json5", renderers.AllFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.json5", renderers.AllFields},
+
 		// toml
 		{"../testdata", "Embedding", "../testdata/embedding.toml", renderers.NoFields},
 		{"../testdata", "Empty", "../testdata/empty.toml", renderers.NoFields},
@@ -65,6 +82,74 @@ func TestGenerator(t *testing.T) {
 		{"../testdata", "Nesting", "../testdata/nesting_all_fields.yaml", renderers.AllFields},
 		{"../testdata", "Simple", "../testdata/simple_all_fields.yaml", renderers.AllFields},
 		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.yaml", renderers.AllFields},
+
+		// ini
+		{"../testdata", "Embedding", "../testdata/embedding.ini", renderers.NoFields},
+		{"../testdata", "Empty", "../testdata/empty.ini", renderers.NoFields},
+		{"../testdata", "Nesting", "../testdata/nesting.ini", renderers.NoFields},
+		{"../testdata", "Simple", "../testdata/simple.ini", renderers.NoFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package.ini", renderers.NoFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_basic_fields.ini", renderers.BasicFields},
+		{"../testdata", "Nesting", "../testdata/nesting_basic_fields.ini", renderers.BasicFields},
+		{"../testdata", "Simple", "../testdata/simple_basic_fields.ini", renderers.BasicFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_basic_fields.ini", renderers.BasicFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_all_fields.ini", renderers.AllFields},
+		{"../testdata", "Nesting", "../testdata/nesting_all_fields.ini", renderers.AllFields},
+		{"../testdata", "Simple", "../testdata/simple_all_fields.ini", renderers.AllFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.ini", renderers.AllFields},
+
+		// dotenv
+		{"../testdata", "Embedding", "../testdata/embedding.env", renderers.NoFields},
+		{"../testdata", "Empty", "../testdata/empty.env", renderers.NoFields},
+		{"../testdata", "Nesting", "../testdata/nesting.env", renderers.NoFields},
+		{"../testdata", "Simple", "../testdata/simple.env", renderers.NoFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package.env", renderers.NoFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_basic_fields.env", renderers.BasicFields},
+		{"../testdata", "Nesting", "../testdata/nesting_basic_fields.env", renderers.BasicFields},
+		{"../testdata", "Simple", "../testdata/simple_basic_fields.env", renderers.BasicFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_basic_fields.env", renderers.BasicFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_all_fields.env", renderers.AllFields},
+		{"../testdata", "Nesting", "../testdata/nesting_all_fields.env", renderers.AllFields},
+		{"../testdata", "Simple", "../testdata/simple_all_fields.env", renderers.AllFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.env", renderers.AllFields},
+
+		// hcl
+		{"../testdata", "Embedding", "../testdata/embedding.hcl", renderers.NoFields},
+		{"../testdata", "Empty", "../testdata/empty.hcl", renderers.NoFields},
+		{"../testdata", "Nesting", "../testdata/nesting.hcl", renderers.NoFields},
+		{"../testdata", "Simple", "../testdata/simple.hcl", renderers.NoFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package.hcl", renderers.NoFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_basic_fields.hcl", renderers.BasicFields},
+		{"../testdata", "Nesting", "../testdata/nesting_basic_fields.hcl", renderers.BasicFields},
+		{"../testdata", "Simple", "../testdata/simple_basic_fields.hcl", renderers.BasicFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_basic_fields.hcl", renderers.BasicFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_all_fields.hcl", renderers.AllFields},
+		{"../testdata", "Nesting", "../testdata/nesting_all_fields.hcl", renderers.AllFields},
+		{"../testdata", "Simple", "../testdata/simple_all_fields.hcl", renderers.AllFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.hcl", renderers.AllFields},
+
+		// properties
+		{"../testdata", "Embedding", "../testdata/embedding.properties", renderers.NoFields},
+		{"../testdata", "Empty", "../testdata/empty.properties", renderers.NoFields},
+		{"../testdata", "Nesting", "../testdata/nesting.properties", renderers.NoFields},
+		{"../testdata", "Simple", "../testdata/simple.properties", renderers.NoFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package.properties", renderers.NoFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_basic_fields.properties", renderers.BasicFields},
+		{"../testdata", "Nesting", "../testdata/nesting_basic_fields.properties", renderers.BasicFields},
+		{"../testdata", "Simple", "../testdata/simple_basic_fields.properties", renderers.BasicFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_basic_fields.properties", renderers.BasicFields},
+
+		{"../testdata", "Embedding", "../testdata/embedding_all_fields.properties", renderers.AllFields},
+		{"../testdata", "Nesting", "../testdata/nesting_all_fields.properties", renderers.AllFields},
+		{"../testdata", "Simple", "../testdata/simple_all_fields.properties", renderers.AllFields},
+		{"../testdata/multipkg", "MultiPackage", "../testdata/multipkg/multi_package_all_fields.properties", renderers.AllFields},
 	}
 
 	whitespacesReplacer := strings.NewReplacer(" ", "◦", "\t", "———➞", "\n", "⏎\n")
@@ -75,12 +160,27 @@ func TestGenerator(t *testing.T) {
 			case ".jsonc":
 				renderer = renderers.NewJsonc(test.mode)
 
+			case ".json5":
+				renderer = renderers.NewJson5(test.mode)
+
 			case ".toml":
 				renderer = renderers.NewToml(test.mode, true)
 
 			case ".yaml":
 				renderer = renderers.NewYaml(test.mode, 2)
 
+			case ".ini":
+				renderer = renderers.NewIni(test.mode)
+
+			case ".env":
+				renderer = renderers.NewDotenv(test.mode)
+
+			case ".hcl":
+				renderer = renderers.NewHcl(test.mode)
+
+			case ".properties":
+				renderer = renderers.NewProperties(test.mode)
+
 			default:
 				t.Fatalf("unsupported file format: %s", test.filename)
 			}
@@ -109,8 +209,13 @@ func TestGenerator(t *testing.T) {
 
 	rr := []renderers.Interface{
 		renderers.NewJsonc(renderers.AllFields),
+		renderers.NewJson5(renderers.AllFields),
 		renderers.NewToml(renderers.AllFields, true),
 		renderers.NewYaml(renderers.AllFields, 2),
+		renderers.NewIni(renderers.AllFields),
+		renderers.NewDotenv(renderers.AllFields),
+		renderers.NewHcl(renderers.AllFields),
+		renderers.NewProperties(renderers.AllFields),
 	}
 
 	for _, r := range rr {
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/modulo-srl/mu-config/go2cfg/generator"
 	"github.com/modulo-srl/mu-config/go2cfg/renderers"
@@ -18,8 +19,9 @@ func main() {
 
 	typeName := flag.String("type", "", "struct type name for which generate config; mandatory")
 	output := flag.String("out", "", "output filepath; The extension in the filepath\n"+
-		"establishes the type of format to be generated, otherwise without extension\n"+
-		"a file for each format will be exported (yaml, toml, jsonc).\n"+
+		"establishes the type of format to be generated (json, jsonc, json5, toml, yaml, ini, env, hcl, properties),\n"+
+		"also as a template, e.g. .env.example,\n"+
+		"otherwise without extension a file for each format will be exported (yaml, toml, jsonc).\n"+
 		"When omitted outputs to stdout in toml format")
 	docTypeMode := flag.String("doc-types", "",
 		"Struct fields types for which render the type in comments:\n"+
@@ -78,6 +80,11 @@ func main() {
 	}
 
 	ext := filepath.Ext(*output)
+	if ext == ".example" {
+		// Template, e.g. .env.example: the format is given by the previous extension.
+		ext = filepath.Ext(strings.TrimSuffix(*output, ext))
+	}
+
 	switch ext {
 	case ".json":
 		fallthrough
@@ -87,6 +94,12 @@ func main() {
 			log.Fatal(err)
 		}
 
+	case ".json5":
+		err = generateJson5File(dir, *typeName, *output, docMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 	case ".toml":
 		err = generateTomlFile(dir, *typeName, *output, docMode)
 		if err != nil {
@@ -99,6 +112,30 @@ func main() {
 			log.Fatal(err)
 		}
 
+	case ".ini", ".conf":
+		err = generateIniFile(dir, *typeName, *output, docMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case ".env":
+		err = generateDotenvFile(dir, *typeName, *output, docMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case ".hcl":
+		err = generateHclFile(dir, *typeName, *output, docMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	case ".properties":
+		err = generatePropertiesFile(dir, *typeName, *output, docMode)
+		if err != nil {
+			log.Fatal(err)
+		}
+
 	default:
 		err = generateJsoncFile(dir, *typeName, *output+".jsonc", docMode)
 		if err != nil {
@@ -145,6 +182,21 @@ func generateJsoncFile(dir, typeName, filename string, docMode renderers.DocType
 	return nil
 }
 
+func generateJson5File(dir, typeName, filename string, docMode renderers.DocTypesMode) error {
+	renderer := renderers.NewJson5(docMode)
+	output, err := generator.Generate(dir, typeName, renderer)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, []byte(output), 0666)
+	if err != nil {
+		return fmt.Errorf("generating JSON5 file: %s", err)
+	}
+
+	return nil
+}
+
 func generateTomlFile(dir, typeName, filename string, docMode renderers.DocTypesMode) error {
 	output, err := generateToml(dir, typeName, docMode)
 	if err != nil {
@@ -183,3 +235,63 @@ func generateYamlFile(dir, typeName, filename string, docMode renderers.DocTypes
 
 	return nil
 }
+
+func generateIniFile(dir, typeName, filename string, docMode renderers.DocTypesMode) error {
+	renderer := renderers.NewIni(docMode)
+	output, err := generator.Generate(dir, typeName, renderer)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, []byte(output), 0666)
+	if err != nil {
+		return fmt.Errorf("generating INI file: %s", err)
+	}
+
+	return nil
+}
+
+func generateDotenvFile(dir, typeName, filename string, docMode renderers.DocTypesMode) error {
+	renderer := renderers.NewDotenv(docMode)
+	output, err := generator.Generate(dir, typeName, renderer)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, []byte(output), 0666)
+	if err != nil {
+		return fmt.Errorf("generating dotenv file: %s", err)
+	}
+
+	return nil
+}
+
+func generateHclFile(dir, typeName, filename string, docMode renderers.DocTypesMode) error {
+	renderer := renderers.NewHcl(docMode)
+	output, err := generator.Generate(dir, typeName, renderer)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, []byte(output), 0666)
+	if err != nil {
+		return fmt.Errorf("generating HCL file: %s", err)
+	}
+
+	return nil
+}
+
+func generatePropertiesFile(dir, typeName, filename string, docMode renderers.DocTypesMode) error {
+	renderer := renderers.NewProperties(docMode)
+	output, err := generator.Generate(dir, typeName, renderer)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filename, []byte(output), 0666)
+	if err != nil {
+		return fmt.Errorf("generating properties file: %s", err)
+	}
+
+	return nil
+}
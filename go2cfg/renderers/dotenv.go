@@ -0,0 +1,245 @@
+package renderers
+
+import (
+	"fmt"
+	"github.com/modulo-srl/mu-config/go2cfg/distiller"
+	"github.com/modulo-srl/mu-config/go2cfg/ordered"
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"go/types"
+	"strings"
+)
+
+// Dotenv renders dotenv (.env) code from distiller info, e.g. a documented .env.example.
+// Nested fields are flattened into upper case variable names joined by '_',
+// e.g. MAIN_PARAMSUB_PARAMARRAY=1,2,3, items of slices or arrays of structs
+// are indexed, e.g. USERS_0_NAME, map keys are kept as written.
+type Dotenv struct {
+	docTypesMode DocTypesMode
+	prefix       string
+	inList       bool
+}
+
+// NewDotenv creates a new dotenv renderer.
+// mode controls the rendering of field types in dotenv comments.
+func NewDotenv(mode DocTypesMode) *Dotenv {
+	return &Dotenv{
+		docTypesMode: mode,
+		prefix:       "",
+	}
+}
+
+func (d *Dotenv) RenderStruct(info *distiller.StructInfo, defaults interface{}, indent string, _ bool, _ []string) (string, error) {
+	var builder strings.Builder
+
+	sorted, sortedDefaults, err := sortFields(info.Fields, defaults)
+	if err != nil {
+		return "", err
+	}
+
+	newline := ""
+
+	for _, field := range sorted {
+		name := field.Name
+
+		builder.WriteString(newline)
+
+		if jsonName, ok := field.Tags["json"]; ok {
+			name = jsonName
+		}
+
+		var value interface{}
+		ok := false
+		if sortedDefaults != nil {
+			value, ok = sortedDefaults[field.Name]
+		}
+
+		consts := distiller.LookupTypedConsts(field.Type.String())
+
+		renderType := d.docTypesMode == AllFields
+
+		simple := isSimpleField(field)
+
+		parent := d.prefix
+		d.prefix = d.renderKey(parent, name, true)
+
+		// No default defined for this field, if named (struct) or array will be rendered below.
+		_, isNamed := field.Type.(*types.Named)
+		if !ok && field.Layout == distiller.LayoutSingle && (consts != nil || !isNamed) {
+			if consts != nil {
+				value = consts[0].Value
+			} else {
+				value = typeZero(field)
+				var basicT *types.Basic
+				basicT, ok = field.Type.(*types.Basic)
+				if ok && basicT.Kind() == types.String {
+					value = d.renderString(value)
+				}
+			}
+		} else {
+			switch field.Layout {
+			case distiller.LayoutSingle:
+				if isNamed && consts == nil {
+					subInfo := distiller.LookupStruct(field.Type.String())
+					if subInfo == nil {
+						return "", fmt.Errorf("cannot lookup structure %s", field.Type.String())
+					}
+
+					value, err = d.RenderStruct(subInfo, value, indent, field.IsEmbedded, nil)
+
+					if err != nil {
+						return "", err
+					}
+				} else {
+					// No special handling required for basic types.
+					var basicT *types.Basic
+					basicT, ok = field.Type.(*types.Basic)
+					if ok && basicT.Kind() == types.String {
+						value = d.renderString(value)
+					}
+					renderType = renderType || (d.docTypesMode == BasicFields)
+				}
+
+			case distiller.LayoutArray:
+				if value == nil {
+					// Add an example item in case of nil array.
+					value, err = d.RenderArray(field, []interface{}{nil}, indent)
+				} else {
+					value, err = d.RenderArray(field, value.([]interface{}), indent)
+				}
+
+			case distiller.LayoutMap:
+				value, err = d.RenderMap(field, value.(*ordered.Map), indent)
+			}
+
+			if err != nil {
+				return "", err
+			}
+		}
+
+		doc := renderDoc(field, indent, "#", renderType)
+		if !simple && field != sorted[0] {
+			doc = "\n" + doc
+		}
+		builder.WriteString(doc)
+
+		if simple {
+			builder.WriteString(fmt.Sprintf("%s%s=%v", indent, d.prefix, value))
+		} else {
+			builder.WriteString(strings.TrimSuffix(fmt.Sprintf("%s%v", indent, value), "\n"))
+		}
+
+		d.prefix = parent
+
+		newline = "\n"
+	}
+
+	return builder.String(), nil
+}
+
+func (d *Dotenv) RenderArray(field *distiller.FieldInfo, value []interface{}, indent string) (string, error) {
+	if len(value) == 0 {
+		return "", nil
+	}
+
+	simple := isSimpleField(field)
+
+	var items []string
+
+	parent := d.prefix
+	d.inList = simple
+	for i, elt := range value {
+		if !simple {
+			d.prefix = fmt.Sprintf("%s_%d", parent, i)
+		}
+
+		literal, err := d.RenderElement(field.EltType, elt, indent)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, literal)
+	}
+	d.inList = false
+	d.prefix = parent
+
+	if simple {
+		return parsers.DotenvValue(strings.Join(items, ", ")), nil
+	}
+
+	return strings.Join(items, "\n\n"), nil
+}
+
+func (d *Dotenv) RenderMap(field *distiller.FieldInfo, value *ordered.Map, indent string) (string, error) {
+	if field.IsEmbedded {
+		return "", fmt.Errorf("field of slice or map type cannot be embedded")
+	}
+
+	code := ""
+
+	var err error
+	value.Iterate(func(key string, elt interface{}) bool {
+		parent := d.prefix
+		d.prefix = d.renderKey(parent, key, false)
+		defer func() { d.prefix = parent }()
+
+		var literal string
+		literal, err = d.RenderElement(field.EltType, elt, indent)
+		if err != nil {
+			return false
+		}
+
+		if _, isBasic := field.EltType.(*types.Basic); isBasic || distiller.LookupTypedConsts(field.EltType.String()) != nil {
+			literal = fmt.Sprintf("%s=%s", d.prefix, literal)
+		}
+
+		code += indent + literal + "\n"
+		return true
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (d *Dotenv) RenderElement(itemType types.Type, item interface{}, indent string) (string, error) {
+	basicT, ok := itemType.(*types.Basic)
+	if ok || distiller.LookupTypedConsts(itemType.String()) != nil {
+		if basicT.Kind() == types.String {
+			return d.renderString(item), nil
+		}
+		return fmt.Sprintf("%v", item), nil
+	}
+
+	subInfo := distiller.LookupStruct(itemType.String())
+	if subInfo == nil {
+		return "", fmt.Errorf("cannot lookup structure %s", itemType.String())
+	}
+
+	return d.RenderStruct(subInfo, item, indent, false, nil)
+}
+
+// renderKey renders the variable name of a field (upper case) or of a map key (as written),
+// removing the quotes of map keys.
+func (d *Dotenv) renderKey(prefix, key string, isField bool) string {
+	key = strings.TrimSuffix(strings.TrimPrefix(key, "\""), "\"")
+	if isField {
+		key = parsers.DotenvKey(key)
+	}
+
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// renderString renders a dotenv string, quoted only when needed;
+// items of lists are quoted as a whole by RenderArray.
+func (d *Dotenv) renderString(v interface{}) string {
+	if d.inList {
+		return parsers.FlatString(unescapeString(v))
+	}
+
+	return parsers.DotenvString(unescapeString(v))
+}
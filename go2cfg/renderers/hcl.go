@@ -0,0 +1,276 @@
+package renderers
+
+import (
+	"fmt"
+	"github.com/modulo-srl/mu-config/go2cfg/distiller"
+	"github.com/modulo-srl/mu-config/go2cfg/ordered"
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"go/types"
+	"strings"
+)
+
+// Hcl renders HCL code from distiller info.
+// Nested structs are rendered as blocks, items of slices or arrays of structs as repeated blocks
+// and maps of structs as labeled blocks, e.g. servers "web" { ... }.
+type Hcl struct {
+	docTypesMode DocTypesMode
+	indent       string
+	name         string
+}
+
+// NewHcl creates a new HCL renderer.
+// mode controls the rendering of field types in HCL comments.
+func NewHcl(mode DocTypesMode) *Hcl {
+	return &Hcl{
+		docTypesMode: mode,
+		indent:       "  ",
+	}
+}
+
+func (h *Hcl) RenderStruct(info *distiller.StructInfo, defaults interface{}, indent string, _ bool, _ []string) (string, error) {
+	var builder strings.Builder
+
+	sorted, sortedDefaults, err := sortFields(info.Fields, defaults)
+	if err != nil {
+		return "", err
+	}
+
+	newline := ""
+
+	for _, field := range sorted {
+		name := field.Name
+
+		builder.WriteString(newline)
+
+		if jsonName, ok := field.Tags["json"]; ok {
+			name = jsonName
+		}
+		name = parsers.HclKey(name)
+
+		var value interface{}
+		ok := false
+		if sortedDefaults != nil {
+			value, ok = sortedDefaults[field.Name]
+		}
+
+		consts := distiller.LookupTypedConsts(field.Type.String())
+
+		renderType := h.docTypesMode == AllFields
+
+		simple := isSimpleField(field)
+
+		// No default defined for this field, if named (struct) or array will be rendered below.
+		_, isNamed := field.Type.(*types.Named)
+		if !ok && field.Layout == distiller.LayoutSingle && (consts != nil || !isNamed) {
+			if consts != nil {
+				value = consts[0].Value
+			} else {
+				value = typeZero(field)
+				var basicT *types.Basic
+				basicT, ok = field.Type.(*types.Basic)
+				if ok && basicT.Kind() == types.String {
+					value = h.renderString(value)
+				}
+			}
+		} else {
+			parent := h.name
+			h.name = name
+
+			switch field.Layout {
+			case distiller.LayoutSingle:
+				if isNamed && consts == nil {
+					subInfo := distiller.LookupStruct(field.Type.String())
+					if subInfo == nil {
+						return "", fmt.Errorf("cannot lookup structure %s", field.Type.String())
+					}
+
+					value, err = h.renderBlock(name, subInfo, value, indent)
+
+					if err != nil {
+						return "", err
+					}
+				} else {
+					// No special handling required for basic types.
+					var basicT *types.Basic
+					basicT, ok = field.Type.(*types.Basic)
+					if ok && basicT.Kind() == types.String {
+						value = h.renderString(value)
+					}
+					renderType = renderType || (h.docTypesMode == BasicFields)
+				}
+
+			case distiller.LayoutArray:
+				if value == nil {
+					// Add an example item in case of nil array.
+					value, err = h.RenderArray(field, []interface{}{nil}, indent)
+				} else {
+					value, err = h.RenderArray(field, value.([]interface{}), indent)
+				}
+
+			case distiller.LayoutMap:
+				value, err = h.RenderMap(field, value.(*ordered.Map), indent)
+			}
+
+			h.name = parent
+
+			if err != nil {
+				return "", err
+			}
+		}
+
+		doc := renderDoc(field, indent, "#", renderType)
+		if !simple && field != sorted[0] {
+			doc = "\n" + doc
+		}
+		builder.WriteString(doc)
+
+		if simple || field.Layout == distiller.LayoutMap && !h.isStructElement(field) {
+			builder.WriteString(fmt.Sprintf("%s%s = %v", indent, name, value))
+		} else {
+			builder.WriteString(fmt.Sprintf("%v", value))
+		}
+
+		newline = "\n"
+	}
+
+	return builder.String(), nil
+}
+
+func (h *Hcl) RenderArray(field *distiller.FieldInfo, value []interface{}, indent string) (string, error) {
+	simple := isSimpleField(field)
+
+	var items []string
+
+	for _, elt := range value {
+		if simple {
+			literal, err := h.RenderElement(field.EltType, elt, indent)
+			if err != nil {
+				return "", err
+			}
+
+			items = append(items, literal)
+			continue
+		}
+
+		subInfo := distiller.LookupStruct(field.EltType.String())
+		if subInfo == nil {
+			return "", fmt.Errorf("cannot lookup structure %s", field.EltType.String())
+		}
+
+		block, err := h.renderBlock(h.name, subInfo, elt, indent)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, block)
+	}
+
+	if simple {
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+
+	return strings.Join(items, "\n\n"), nil
+}
+
+func (h *Hcl) RenderMap(field *distiller.FieldInfo, value *ordered.Map, indent string) (string, error) {
+	if field.IsEmbedded {
+		return "", fmt.Errorf("field of slice or map type cannot be embedded")
+	}
+
+	isStruct := h.isStructElement(field)
+
+	if value.Len() == 0 {
+		if isStruct {
+			return "", nil
+		}
+		return "{}", nil
+	}
+
+	var items []string
+
+	name := h.name
+
+	var err error
+	value.Iterate(func(key string, elt interface{}) bool {
+		key = strings.TrimSuffix(strings.TrimPrefix(key, "\""), "\"")
+
+		if isStruct {
+			// Labeled block.
+			subInfo := distiller.LookupStruct(field.EltType.String())
+			if subInfo == nil {
+				err = fmt.Errorf("cannot lookup structure %s", field.EltType.String())
+				return false
+			}
+
+			var block string
+			block, err = h.renderBlock(name+" "+parsers.HclString(key), subInfo, elt, indent)
+			if err != nil {
+				return false
+			}
+
+			items = append(items, block)
+			return true
+		}
+
+		var literal string
+		literal, err = h.RenderElement(field.EltType, elt, indent+h.indent)
+		if err != nil {
+			return false
+		}
+
+		items = append(items, fmt.Sprintf("%s%s%s = %s", indent, h.indent, parsers.HclKey(key), literal))
+		return true
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if isStruct {
+		return strings.Join(items, "\n\n"), nil
+	}
+
+	return "{\n" + strings.Join(items, "\n") + "\n" + indent + "}", nil
+}
+
+func (h *Hcl) RenderElement(itemType types.Type, item interface{}, indent string) (string, error) {
+	basicT, ok := itemType.(*types.Basic)
+	if ok || distiller.LookupTypedConsts(itemType.String()) != nil {
+		if basicT.Kind() == types.String {
+			return h.renderString(item), nil
+		}
+		return fmt.Sprintf("%v", item), nil
+	}
+
+	subInfo := distiller.LookupStruct(itemType.String())
+	if subInfo == nil {
+		return "", fmt.Errorf("cannot lookup structure %s", itemType.String())
+	}
+
+	return h.RenderStruct(subInfo, item, indent, false, nil)
+}
+
+// renderBlock renders a struct as a block with the given header (block type and labels).
+func (h *Hcl) renderBlock(header string, info *distiller.StructInfo, defaults interface{}, indent string) (string, error) {
+	body, err := h.RenderStruct(info, defaults, indent+h.indent, false, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if body == "" {
+		return fmt.Sprintf("%s%s {}", indent, header), nil
+	}
+
+	return fmt.Sprintf("%s%s {\n%s\n%s}", indent, header, body, indent), nil
+}
+
+// isStructElement verifies that the elements of a map field are structs, rendered as labeled blocks.
+func (h *Hcl) isStructElement(field *distiller.FieldInfo) bool {
+	_, isNamed := field.EltType.(*types.Named)
+	return isNamed && distiller.LookupTypedConsts(field.EltType.String()) == nil
+}
+
+// renderString renders an HCL quoted string.
+func (h *Hcl) renderString(v interface{}) string {
+	return parsers.HclString(unescapeString(v))
+}
@@ -0,0 +1,236 @@
+package renderers
+
+import (
+	"fmt"
+	"github.com/modulo-srl/mu-config/go2cfg/distiller"
+	"github.com/modulo-srl/mu-config/go2cfg/ordered"
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"go/types"
+	"strings"
+)
+
+// Ini renders INI code from distiller info.
+// Nested structs and maps are rendered as [section] and [section.sub] sections,
+// items of slices or arrays of structs as sections with numeric index, e.g. [section.0].
+type Ini struct {
+	docTypesMode DocTypesMode
+	path         string
+}
+
+// NewIni creates a new INI renderer.
+// mode controls the rendering of field types in INI comments.
+func NewIni(mode DocTypesMode) *Ini {
+	return &Ini{
+		docTypesMode: mode,
+		path:         "",
+	}
+}
+
+func (n *Ini) RenderStruct(info *distiller.StructInfo, defaults interface{}, indent string, embedded bool, _ []string) (string, error) {
+	var builder strings.Builder
+
+	if !embedded && len(n.path) > 0 {
+		builder.WriteString(fmt.Sprintf("[%s]\n", n.path))
+	}
+
+	sorted, sortedDefaults, err := sortFields(info.Fields, defaults)
+	if err != nil {
+		return "", err
+	}
+
+	newline := ""
+
+	for _, field := range sorted {
+		name := field.Name
+
+		builder.WriteString(newline)
+
+		if jsonName, ok := field.Tags["json"]; ok {
+			name = n.renderKey(jsonName)
+		}
+
+		var value interface{}
+		ok := false
+		if sortedDefaults != nil {
+			value, ok = sortedDefaults[field.Name]
+		}
+
+		consts := distiller.LookupTypedConsts(field.Type.String())
+
+		renderType := n.docTypesMode == AllFields
+
+		simple := isSimpleField(field)
+
+		parent := n.path
+		if len(n.path) > 0 {
+			n.path += "."
+		}
+		n.path += name
+
+		// No default defined for this field, if named (struct) or array will be rendered below.
+		_, isNamed := field.Type.(*types.Named)
+		if !ok && field.Layout == distiller.LayoutSingle && (consts != nil || !isNamed) {
+			if consts != nil {
+				value = consts[0].Value
+			} else {
+				value = typeZero(field)
+				var basicT *types.Basic
+				basicT, ok = field.Type.(*types.Basic)
+				if ok && basicT.Kind() == types.String {
+					value = n.renderString(value)
+				}
+			}
+		} else {
+			switch field.Layout {
+			case distiller.LayoutSingle:
+				if isNamed && consts == nil {
+					subInfo := distiller.LookupStruct(field.Type.String())
+					if subInfo == nil {
+						return "", fmt.Errorf("cannot lookup structure %s", field.Type.String())
+					}
+
+					value, err = n.RenderStruct(subInfo, value, indent, field.IsEmbedded, nil)
+
+					if err != nil {
+						return "", err
+					}
+				} else {
+					// No special handling required for basic types.
+					var basicT *types.Basic
+					basicT, ok = field.Type.(*types.Basic)
+					if ok && basicT.Kind() == types.String {
+						value = n.renderString(value)
+					}
+					renderType = renderType || (n.docTypesMode == BasicFields)
+				}
+
+			case distiller.LayoutArray:
+				if value == nil {
+					// Add an example item in case of nil array.
+					value, err = n.RenderArray(field, []interface{}{nil}, indent)
+				} else {
+					value, err = n.RenderArray(field, value.([]interface{}), indent)
+				}
+
+			case distiller.LayoutMap:
+				value, err = n.RenderMap(field, value.(*ordered.Map), indent)
+			}
+
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if field.IsEmbedded {
+			builder.WriteString(fmt.Sprintf("%v", value))
+		} else {
+			doc := renderDoc(field, indent, ";", renderType)
+			if !simple && field != sorted[0] {
+				doc = "\n" + doc
+			}
+			builder.WriteString(doc)
+
+			if simple {
+				builder.WriteString(strings.TrimRight(fmt.Sprintf("%s%s = %v", indent, name, value), " "))
+			} else if field.Layout == distiller.LayoutMap {
+				builder.WriteString(fmt.Sprintf("%s[%s]\n%v", indent, n.path, value))
+			} else {
+				builder.WriteString(fmt.Sprintf("%s%v", indent, value))
+			}
+		}
+
+		n.path = parent
+
+		newline = "\n"
+	}
+
+	return builder.String(), nil
+}
+
+func (n *Ini) RenderArray(field *distiller.FieldInfo, value []interface{}, indent string) (string, error) {
+	if len(value) == 0 {
+		return "", nil
+	}
+
+	simple := isSimpleField(field)
+	separator := "\n\n"
+	if simple {
+		separator = ", "
+	}
+
+	var items []string
+
+	parent := n.path
+	for i, elt := range value {
+		if !simple {
+			n.path = fmt.Sprintf("%s.%d", parent, i)
+		}
+
+		literal, err := n.RenderElement(field.EltType, elt, indent)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, literal)
+	}
+	n.path = parent
+
+	return strings.Join(items, separator), nil
+}
+
+func (n *Ini) RenderMap(field *distiller.FieldInfo, value *ordered.Map, indent string) (string, error) {
+	if field.IsEmbedded {
+		return "", fmt.Errorf("field of slice or map type cannot be embedded")
+	}
+
+	if value.Len() == 0 {
+		return "\n", nil
+	}
+
+	code := ""
+
+	var err error
+	value.Iterate(func(key string, elt interface{}) bool {
+		var literal string
+		literal, err = n.RenderElement(field.EltType, elt, indent)
+		if err != nil {
+			return false
+		}
+
+		code += strings.TrimRight(indent+fmt.Sprintf("%s = %s", n.renderKey(key), literal), " ") + "\n"
+		return true
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (n *Ini) RenderElement(itemType types.Type, item interface{}, indent string) (string, error) {
+	basicT, ok := itemType.(*types.Basic)
+	if ok || distiller.LookupTypedConsts(itemType.String()) != nil {
+		if basicT.Kind() == types.String {
+			return n.renderString(item), nil
+		}
+		return fmt.Sprintf("%v", item), nil
+	}
+
+	subInfo := distiller.LookupStruct(itemType.String())
+	if subInfo == nil {
+		return "", fmt.Errorf("cannot lookup structure %s", itemType.String())
+	}
+
+	return n.RenderStruct(subInfo, item, indent, false, nil)
+}
+
+// renderKey renders an INI key, removing the quotes of map keys.
+func (n *Ini) renderKey(key string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(key, "\""), "\"")
+}
+
+// renderString renders an INI string, quoted only when needed.
+func (n *Ini) renderString(v interface{}) string {
+	return parsers.FlatString(unescapeString(v))
+}
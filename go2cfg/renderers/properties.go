@@ -0,0 +1,240 @@
+package renderers
+
+import (
+	"fmt"
+	"github.com/modulo-srl/mu-config/go2cfg/distiller"
+	"github.com/modulo-srl/mu-config/go2cfg/ordered"
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"go/types"
+	"strings"
+)
+
+// Properties renders Java properties code from distiller info.
+// Nested fields are flattened into keys joined by '.', e.g. main.paramSub.paramArray=1, 2, 3,
+// items of slices or arrays of structs are indexed, e.g. users.0.name, map keys are kept as written.
+type Properties struct {
+	docTypesMode DocTypesMode
+	prefix       string
+	inList       bool
+}
+
+// NewProperties creates a new Java properties renderer.
+// mode controls the rendering of field types in properties comments.
+func NewProperties(mode DocTypesMode) *Properties {
+	return &Properties{
+		docTypesMode: mode,
+		prefix:       "",
+	}
+}
+
+func (p *Properties) RenderStruct(info *distiller.StructInfo, defaults interface{}, indent string, _ bool, _ []string) (string, error) {
+	var builder strings.Builder
+
+	sorted, sortedDefaults, err := sortFields(info.Fields, defaults)
+	if err != nil {
+		return "", err
+	}
+
+	newline := ""
+
+	for _, field := range sorted {
+		name := field.Name
+
+		builder.WriteString(newline)
+
+		if jsonName, ok := field.Tags["json"]; ok {
+			name = jsonName
+		}
+
+		var value interface{}
+		ok := false
+		if sortedDefaults != nil {
+			value, ok = sortedDefaults[field.Name]
+		}
+
+		consts := distiller.LookupTypedConsts(field.Type.String())
+
+		renderType := p.docTypesMode == AllFields
+
+		simple := isSimpleField(field)
+
+		parent := p.prefix
+		p.prefix = p.renderKey(parent, name)
+
+		// No default defined for this field, if named (struct) or array will be rendered below.
+		_, isNamed := field.Type.(*types.Named)
+		if !ok && field.Layout == distiller.LayoutSingle && (consts != nil || !isNamed) {
+			if consts != nil {
+				value = consts[0].Value
+			} else {
+				value = typeZero(field)
+				var basicT *types.Basic
+				basicT, ok = field.Type.(*types.Basic)
+				if ok && basicT.Kind() == types.String {
+					value = p.renderString(value)
+				}
+			}
+		} else {
+			switch field.Layout {
+			case distiller.LayoutSingle:
+				if isNamed && consts == nil {
+					subInfo := distiller.LookupStruct(field.Type.String())
+					if subInfo == nil {
+						return "", fmt.Errorf("cannot lookup structure %s", field.Type.String())
+					}
+
+					value, err = p.RenderStruct(subInfo, value, indent, field.IsEmbedded, nil)
+
+					if err != nil {
+						return "", err
+					}
+				} else {
+					// No special handling required for basic types.
+					var basicT *types.Basic
+					basicT, ok = field.Type.(*types.Basic)
+					if ok && basicT.Kind() == types.String {
+						value = p.renderString(value)
+					}
+					renderType = renderType || (p.docTypesMode == BasicFields)
+				}
+
+			case distiller.LayoutArray:
+				if value == nil {
+					// Add an example item in case of nil array.
+					value, err = p.RenderArray(field, []interface{}{nil}, indent)
+				} else {
+					value, err = p.RenderArray(field, value.([]interface{}), indent)
+				}
+
+			case distiller.LayoutMap:
+				value, err = p.RenderMap(field, value.(*ordered.Map), indent)
+			}
+
+			if err != nil {
+				return "", err
+			}
+		}
+
+		doc := renderDoc(field, indent, "#", renderType)
+		if !simple && field != sorted[0] {
+			doc = "\n" + doc
+		}
+		builder.WriteString(doc)
+
+		if simple {
+			builder.WriteString(fmt.Sprintf("%s%s=%v", indent, p.prefix, value))
+		} else {
+			builder.WriteString(strings.TrimSuffix(fmt.Sprintf("%s%v", indent, value), "\n"))
+		}
+
+		p.prefix = parent
+
+		newline = "\n"
+	}
+
+	return builder.String(), nil
+}
+
+func (p *Properties) RenderArray(field *distiller.FieldInfo, value []interface{}, indent string) (string, error) {
+	if len(value) == 0 {
+		return "", nil
+	}
+
+	simple := isSimpleField(field)
+
+	var items []string
+
+	parent := p.prefix
+	p.inList = simple
+	for i, elt := range value {
+		if !simple {
+			p.prefix = fmt.Sprintf("%s.%d", parent, i)
+		}
+
+		literal, err := p.RenderElement(field.EltType, elt, indent)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, literal)
+	}
+	p.inList = false
+	p.prefix = parent
+
+	if simple {
+		return parsers.PropertiesValue(strings.Join(items, ", ")), nil
+	}
+
+	return strings.Join(items, "\n\n"), nil
+}
+
+func (p *Properties) RenderMap(field *distiller.FieldInfo, value *ordered.Map, indent string) (string, error) {
+	if field.IsEmbedded {
+		return "", fmt.Errorf("field of slice or map type cannot be embedded")
+	}
+
+	code := ""
+
+	var err error
+	value.Iterate(func(key string, elt interface{}) bool {
+		parent := p.prefix
+		p.prefix = p.renderKey(parent, key)
+		defer func() { p.prefix = parent }()
+
+		var literal string
+		literal, err = p.RenderElement(field.EltType, elt, indent)
+		if err != nil {
+			return false
+		}
+
+		if _, isBasic := field.EltType.(*types.Basic); isBasic || distiller.LookupTypedConsts(field.EltType.String()) != nil {
+			literal = fmt.Sprintf("%s=%s", p.prefix, literal)
+		}
+
+		code += indent + literal + "\n"
+		return true
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (p *Properties) RenderElement(itemType types.Type, item interface{}, indent string) (string, error) {
+	basicT, ok := itemType.(*types.Basic)
+	if ok || distiller.LookupTypedConsts(itemType.String()) != nil {
+		if basicT.Kind() == types.String {
+			return p.renderString(item), nil
+		}
+		return fmt.Sprintf("%v", item), nil
+	}
+
+	subInfo := distiller.LookupStruct(itemType.String())
+	if subInfo == nil {
+		return "", fmt.Errorf("cannot lookup structure %s", itemType.String())
+	}
+
+	return p.RenderStruct(subInfo, item, indent, false, nil)
+}
+
+// renderKey renders the key of a field or of a map key, removing the quotes of map keys.
+func (p *Properties) renderKey(prefix, key string) string {
+	key = parsers.PropertiesKey(strings.TrimSuffix(strings.TrimPrefix(key, "\""), "\""))
+
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// renderString renders a properties string, quoted only when needed;
+// items of lists are escaped as a whole by RenderArray.
+func (p *Properties) renderString(v interface{}) string {
+	if p.inList {
+		return parsers.FlatString(unescapeString(v))
+	}
+
+	return parsers.PropertiesValue(parsers.FlatString(unescapeString(v)))
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/pelletier/go-toml/v2"
 	"go/types"
 	"regexp"
-	"sort"
 	"strings"
 )
 
@@ -43,7 +42,7 @@ func (t *Toml) RenderStruct(info *distiller.StructInfo, defaults interface{}, in
 		}
 	}
 
-	sorted, sortedDefaults, err := t.sortFields(info.Fields, defaults)
+	sorted, sortedDefaults, err := sortFields(info.Fields, defaults)
 	if err != nil {
 		return "", err
 	}
@@ -69,7 +68,7 @@ func (t *Toml) RenderStruct(info *distiller.StructInfo, defaults interface{}, in
 
 		renderType := t.docTypesMode == AllFields
 
-		simple := t.isSimpleField(field)
+		simple := isSimpleField(field)
 
 		fieldIndent := indent
 		if t.indented && !simple && len(t.path) > 0 {
@@ -173,7 +172,7 @@ func (t *Toml) RenderArray(field *distiller.FieldInfo, value []interface{}, inde
 		return "", nil
 	}
 
-	simple := t.isSimpleField(field)
+	simple := isSimpleField(field)
 	code := ""
 	separator := "\n\n"
 	eltsIndent := indent
@@ -249,82 +248,6 @@ func (t *Toml) RenderElement(itemType types.Type, item interface{}, indent strin
 	return t.RenderStruct(subInfo, item, indent, false, nil)
 }
 
-// sortFields sorts the fields by putting those that have basic types or that are
-// slices or arrays of basic types first, taking shadowing and embedding into account.
-// It also returns the defaults to simplify access in case of embedding.
-func (t *Toml) sortFields(fields []*distiller.FieldInfo, defaults interface{}) ([]*distiller.FieldInfo, map[string]interface{}, error) {
-	var sorted []*distiller.FieldInfo
-	fieldsDefaults := make(map[string]interface{})
-
-	for _, field := range fields {
-		if !field.IsEmbedded {
-			if i := fieldsSlice(sorted).indexOf(field.Name); i != -1 {
-				sorted = append(sorted[0:i], sorted[i+1:]...)
-			}
-			sorted = append(sorted, field)
-			if defaults != nil {
-				if value, ok := defaults.(map[string]interface{})[field.Name]; ok {
-					fieldsDefaults[field.Name] = value
-				}
-			}
-			continue
-		}
-
-		key := field.Type.String()
-		if pathEnd := strings.LastIndex(key, "/"); pathEnd >= 0 {
-			key = key[pathEnd+strings.Index(key[pathEnd+1:], ".")+2:]
-		}
-
-		subInfo := distiller.LookupStruct(field.Type.String())
-		if subInfo == nil {
-			return nil, nil, fmt.Errorf("cannot lookup structure %s", field.Type.String())
-		}
-
-		var defaultsMap interface{}
-		if defaults != nil {
-			defaultsMap = defaults.(map[string]interface{})[key]
-		}
-		subFields, subDefaults, err := t.sortFields(subInfo.Fields, defaultsMap)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		for _, subField := range subFields {
-			if i := fieldsSlice(sorted).indexOf(subField.Name); i != -1 {
-				sorted = append(sorted[0:i], sorted[i+1:]...)
-			}
-			sorted = append(sorted, subField)
-
-			if value, ok := subDefaults[subField.Name]; ok {
-				fieldsDefaults[subField.Name] = value
-			}
-		}
-	}
-
-	sort.SliceStable(sorted, func(i, j int) bool {
-		return t.isSimpleField(sorted[i]) && !t.isSimpleField(sorted[j])
-	})
-
-	return sorted, fieldsDefaults, nil
-}
-
-// isSimpleField verifies that a field is simple, i.e. that it is of native type or an array or slice of native types.
-func (t *Toml) isSimpleField(field *distiller.FieldInfo) bool {
-	_, isNamed := field.Type.(*types.Named)
-	consts := distiller.LookupTypedConsts(field.Type.String())
-	switch field.Layout {
-	case distiller.LayoutSingle:
-		return consts != nil || !isNamed
-
-	case distiller.LayoutArray:
-		_, isNamed = field.EltType.(*types.Named)
-		consts = distiller.LookupTypedConsts(field.EltType.String())
-		return consts != nil || !isNamed
-	}
-
-	return false
-}
-
 // renderKey renders a TOML key surrounding it with quotes when needed.
 func (t *Toml) renderKey(key string) string {
 	key = strings.TrimSuffix(strings.TrimPrefix(key, "\""), "\"")
@@ -0,0 +1,19 @@
+# int - PacketLoss documentation block.
+# Packet loss comment.
+packet_loss = 64
+# int - Round-trip time in milliseconds.
+round_trip_time = 123
+
+# network.Status - Network status.
+NetStatus {
+  # bool - Connected flag comment.
+  Connected = true
+  # network.ConnState - Connection state comment.
+  # Allowed values:
+  # StateDisconnected = 0  StateDisconnected signals the Disconnected state.
+  # StateConnecting   = 1  StateConnecting signals the connection-pending state.
+  # StateConnected    = 2  StateConnected signals the Connected state.
+  # StateFailed       = 5  StateFailed signals the Failed state.
+  # StateReconnecting = 6  StateReconnecting signals the Reconnecting state.
+  State = 0
+}
\ No newline at end of file
@@ -0,0 +1,35 @@
+# string - Name of the user documentation block.
+# User name comment.
+Name = "John"
+# User surname comment.
+Surname = ""
+# int - Age documentation block.
+# User age.
+age = 30
+# int - Number of stars achieved.
+stars_count = 5
+# Addresses comment.
+Addresses = ["Address 1", "Address 2", "Address 3"]
+# Type documentation block.
+# Type of constant.
+# Allowed values:
+# ConstTypeA =   0  ConstTypeA doc block. ConstTypeA comment.
+# ConstTypeB =   1  ConstTypeB comment.
+# ConstTypeC =   2  ConstTypeC doc block. ConstTypeC comment.
+# ConstTypeD =  32  ConstTypeD doc block.
+# ConstTypeE =  64  ConstTypeE doc block. ConstTypeE comment.
+# ConstTypeF = 128  ConstTypeF doc block. ConstTypeF comment.
+Type = 0
+# float64 - X, Y documentation block.
+# Coordinates.
+X = 1
+# float64 - X, Y documentation block.
+# Coordinates.
+Y = 2
+
+# User tags.
+Tags = {
+  Key1 = "Value1"
+  Key2 = "Value2"
+  Key3 = "Value3"
+}
\ No newline at end of file
@@ -1,7 +1,9 @@
 package settings
 
 import (
+	"bytes"
 	"encoding/json"
+	"reflect"
 
 	"gitlab.com/c0b/go-ordered-json"
 )
@@ -9,56 +11,67 @@ import (
 // Effettua la differenza tra due entità dati ritornando i soli campi con valori differenti (out = child - parent).
 // Campi con lo stesso nome nelle due struct devono essere dello stesso tipo.
 // Ritorna una map ordinata in modo da mantenere il medesimo ordine dei campi originali.
+//   - le map annidate riportano le sole chiavi modificate o presenti solo in child
+//     (le chiavi rimosse non sono rappresentabili, poiché i file vengono caricati in sovrapposizione);
+//   - gli array che differiscono, ad es. slice di struct, sono riportati per intero;
+//   - parent nil equivale a una entità priva di campi.
 func diff(parent, child interface{}) (diffedMap *ordered.OrderedMap, err error) {
-	marshUnmarsh := func(m interface{}) (out *ordered.OrderedMap, err error) {
-		b, err := json.Marshal(&m)
-		if err != nil {
-			return nil, err
-		}
-
-		out = ordered.NewOrderedMap()
-
-		err = json.Unmarshal(b, out)
-		if err != nil {
-			return nil, err
-		}
+	var m1, m2 *ordered.OrderedMap
 
-		return
+	m1, err = orderedTree(parent)
+	if err != nil {
+		return nil, err
+	}
+	m2, err = orderedTree(child)
+	if err != nil {
+		return nil, err
 	}
 
-	var m1, m2 *ordered.OrderedMap
+	return diffMaps(m1, m2), nil
+}
 
-	m1, err = marshUnmarsh(&parent)
+// Converte i dati in una map ordinata, tramite la codifica Json; nil corrisponde a una map vuota.
+func orderedTree(m interface{}) (out *ordered.OrderedMap, err error) {
+	b, err := json.Marshal(&m)
 	if err != nil {
 		return nil, err
 	}
-	m2, err = marshUnmarsh(&child)
+
+	out = ordered.NewOrderedMap()
+
+	if bytes.Equal(b, []byte("null")) {
+		return out, nil
+	}
+
+	err = json.Unmarshal(b, out)
 	if err != nil {
 		return nil, err
 	}
 
-	return diffMaps(m1, m2), nil
+	return
 }
 
 func diffMaps(mapParent, mapChild *ordered.OrderedMap) *ordered.OrderedMap {
 	mapOut := ordered.NewOrderedMap()
 
-	iter := mapParent.EntriesIter()
+	iter := mapChild.EntriesIter()
 	for {
 		pair, ok := iter()
 		if !ok {
 			break
 		}
 		k := pair.Key
-		v1 := pair.Value
+		v2 := pair.Value
 
-		v2, ok := mapChild.GetValue(k)
+		v1, ok := mapParent.GetValue(k)
 		if !ok {
+			// Chiave presente solo in child.
+			mapOut.Set(k, v2)
 			continue
 		}
 
-		vd := diffFields(v1, v2)
-		if vd != nil {
+		vd, changed := diffFields(v1, v2)
+		if changed {
 			mapOut.Set(k, vd)
 		}
 	}
@@ -66,27 +79,28 @@ func diffMaps(mapParent, mapChild *ordered.OrderedMap) *ordered.OrderedMap {
 	return mapOut
 }
 
-func diffArrays(arr1, arr2 []interface{}) []interface{} {
+func diffArrays(arr1, arr2 []interface{}) bool {
 	if len(arr1) != len(arr2) {
-		return arr2
+		return true
 	}
 
 	for i := range arr1 {
-		f := diffFields(arr1[i], arr2[i])
-		if f != nil {
-			return arr2
+		_, changed := diffFields(arr1[i], arr2[i])
+		if changed {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
 
-func diffFields(field1, field2 interface{}) interface{} {
+// Ritorna la differenza tra i due valori e se questi differiscono.
+func diffFields(field1, field2 interface{}) (interface{}, bool) {
 	// Map
 	if m1, ok := field1.(*ordered.OrderedMap); ok {
 		m2, ok := field2.(*ordered.OrderedMap)
-		if !ok || m2 == nil {
-			return nil
+		if !ok {
+			return field2, true
 		}
 
 		dm := diffMaps(m1, m2)
@@ -94,89 +108,22 @@ func diffFields(field1, field2 interface{}) interface{} {
 		_, ok = i()
 		if !ok {
 			// empty map
-			return nil
+			return nil, false
 		}
 
-		return dm
+		return dm, true
 	}
 
 	// Array
 	if a1, ok := field1.([]interface{}); ok {
 		a2, ok := field2.([]interface{})
 		if !ok {
-			return nil
-		}
-
-		i := diffArrays(a1, a2)
-		if i == nil {
-			return nil // evita il cast a slice vuoto
+			return field2, true
 		}
-		return i
-	}
-
-	// Boolean
-	if b1, ok := field1.(bool); ok {
-		b2, ok := field2.(bool)
-		if !ok || b1 == b2 {
-			return nil
-		}
-		return field2
-	}
 
-	// Integer
-	if i1, ok := field1.(int); ok {
-		i2, ok := field2.(int)
-		if !ok || i1 == i2 {
-			return nil
-		}
-		return field2
-	}
-
-	// Float64
-	if f1, ok := field1.(float64); ok {
-		f2, ok := field2.(float64)
-		if !ok || f1 == f2 {
-			return nil
-		}
-		return field2
-	}
-
-	// Float32
-	if f1, ok := field1.(float32); ok {
-		f2, ok := field2.(float32)
-		if !ok || f1 == f2 {
-			return nil
-		}
-		return field2
+		return a2, diffArrays(a1, a2)
 	}
 
-	// JSON Number
-	if b1, ok := field1.(json.Number); ok {
-		b2, ok := field2.(json.Number)
-		if !ok || b1 == b2 {
-			return nil
-		}
-		return field2
-	}
-
-	// String
-	if s1, ok := field1.(string); ok {
-		s2, ok := field2.(string)
-		if !ok || s1 == s2 {
-			return nil
-		}
-		return field2
-	}
-
-	return nil
+	// Valori semplici (json.Number, stringhe, booleani, null).
+	return field2, !reflect.DeepEqual(field1, field2)
 }
-
-/*func cloneData(from, to interface{}) error {
-	bout, err := json.Marshal(&from)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(bout, &to)
-	return err
-}*/
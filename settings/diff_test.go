@@ -39,3 +39,55 @@ func TestDiff(t *testing.T) {
 		t.Fatal("Mismatch output\nDiff:     " + string(j) + "\nExpected: " + jsonExpected + "\n")
 	}
 }
+
+func TestDiffNested(t *testing.T) {
+	type item struct {
+		Name string
+		Tags map[string]string
+	}
+	type config struct {
+		Items  []item
+		Labels map[string]int
+		Ptr    *int
+	}
+
+	one := 1
+	parent := config{
+		Items:  []item{{Name: "a"}, {Name: "b"}},
+		Labels: map[string]int{"x": 1, "y": 2},
+	}
+	child := config{
+		Items:  []item{{Name: "a"}, {Name: "b", Tags: map[string]string{"k": "v"}}},
+		Labels: map[string]int{"x": 1, "y": 3, "z": 4},
+		Ptr:    &one,
+	}
+
+	mapDiff, err := diff(parent, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := mapDiff.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonExpected := `{"Items":[{"Name":"a","Tags":null},{"Name":"b","Tags":{"k":"v"}}],"Labels":{"y":3,"z":4},"Ptr":1}`
+	if string(j) != jsonExpected {
+		t.Fatal("Mismatch output\nDiff:     " + string(j) + "\nExpected: " + jsonExpected + "\n")
+	}
+
+	// Senza parent: tutti i campi.
+	mapDiff, err = diff(nil, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err = mapDiff.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonExpected = `{"Items":[{"Name":"a","Tags":null},{"Name":"b","Tags":null}],"Labels":{"x":1,"y":2},"Ptr":null}`
+	if string(j) != jsonExpected {
+		t.Fatal("Mismatch output\nDiff:     " + string(j) + "\nExpected: " + jsonExpected + "\n")
+	}
+}
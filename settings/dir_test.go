@@ -0,0 +1,47 @@
+package settings
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"10-base.toml":     "[main]\nparamint = 13\nparamstring = 'base'\n",
+		"20-override.yaml": "main:\n  paramstring: override\n",
+		"30-last.jsonc":    `{ "main": { "paramfloat": 2.5 } }`,
+		".hidden.yaml":     "main:\n  paramint: 99\n",
+		"README.txt":       "ignored",
+		"sub/99-skip.yaml": "main:\n  paramint: 99\n",
+	})
+
+	data := defaultSettings()
+
+	loaded, err := LoadDir(dir, &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) != 3 || loaded[0] != filepath.Join(dir, "10-base.toml") || loaded[2] != filepath.Join(dir, "30-last.jsonc") {
+		t.Fatalf("unexpected loaded files %v", loaded)
+	}
+
+	if !data.Main.ParamBool {
+		t.Fatal("defaults non persistono")
+	}
+	if data.Main.ParamInt != 13 || data.Main.ParamString != "override" || data.Main.ParamFloat != 2.5 {
+		t.Fatal("override non settati")
+	}
+
+	loaded, err = LoadDir(filepath.Join(dir, "missing"), &data, false)
+	if err != nil || loaded != nil {
+		t.Fatal("missing dir not ignored")
+	}
+
+	_, err = LoadDir(filepath.Join(dir, "missing"), &data, true)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,201 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Carica la configurazione dalle variabili d'ambiente, a mo' di override.
+//   - prefix: prefisso delle variabili; i nomi dei campi annidati sono separati da '_',
+//     ad es. con prefisso "MYAPP" la variabile MYAPP_MAIN_PARAMINT imposta Main.ParamInt.
+//     I nomi sono case insensitive, come nei decoder Json, Yaml e Toml.
+//   - cfg: PUNTATORE a struttura configurazione da popolare.
+//
+// I campi complessi (slice, array, map) accettano un valore Json.
+func LoadEnv(prefix string, cfg interface{}) error {
+	_, err := loadEnv(environ(), prefix, cfg)
+	return err
+}
+
+// Ritorna le variabili d'ambiente indicizzate per nome in maiuscolo.
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		env[strings.ToUpper(parts[0])] = parts[1]
+	}
+
+	return env
+}
+
+// Funzione interna per popolare la configurazione da una mappa di variabili (nomi in maiuscolo).
+// Ritorna i percorsi dei campi impostati (vedi Provenance), vuoto se nessuna variabile è stata applicata.
+func loadEnv(env map[string]string, prefix string, cfg interface{}) (applied []string, err error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config data must be a pointer to struct")
+	}
+
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix) + "_"
+	}
+
+	err = loadEnvStruct(env, prefix, "", v.Elem(), &applied)
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Popola ricorsivamente i campi della struct v dalle variabili env che iniziano per prefix.
+//   - path: percorso della struct v, a cui vengono aggiunti i campi impostati in applied.
+func loadEnvStruct(env map[string]string, prefix, path string, v reflect.Value, applied *[]string) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		// Le struct embedded condividono il livello di annidamento del contenitore.
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			err := loadEnvNested(env, prefix, path, fv, applied)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := prefix + strings.ToUpper(field.Name)
+		fieldPath := joinPath(path, field.Name)
+
+		if value, ok := env[name]; ok {
+			err := setEnvValue(fv, value)
+			if err != nil {
+				return fmt.Errorf("cannot parse %s: %s", name, err)
+			}
+			*applied = append(*applied, fieldPath)
+			continue
+		}
+
+		if indirectType(field.Type).Kind() == reflect.Struct && hasEnvPrefix(env, name+"_") {
+			err := loadEnvNested(env, name+"_", fieldPath, fv, applied)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Popola la struct annidata fv (struct o puntatore a struct) dalle variabili che iniziano per prefix.
+// Un puntatore nil viene allocato solo se almeno una variabile viene applicata.
+func loadEnvNested(env map[string]string, prefix, path string, fv reflect.Value, applied *[]string) error {
+	if fv.Kind() != reflect.Pointer || !fv.IsNil() {
+		return loadEnvStruct(env, prefix, path, allocValue(fv), applied)
+	}
+
+	nv := reflect.New(fv.Type().Elem())
+	n := len(*applied)
+
+	err := loadEnvStruct(env, prefix, path, nv.Elem(), applied)
+	if err != nil || len(*applied) == n {
+		return err
+	}
+
+	fv.Set(nv)
+	return nil
+}
+
+// Imposta il valore di un campo a partire dal contenuto testuale della variabile.
+// I numeri interi sono in base 10, come nei decoder Json, Yaml e Toml (ad es. "010" vale 10).
+func setEnvValue(v reflect.Value, value string) error {
+	v = allocValue(v)
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		// Valori complessi: Json.
+		d := json.NewDecoder(bytes.NewReader([]byte(value)))
+		d.DisallowUnknownFields()
+
+		return d.Decode(v.Addr().Interface())
+	}
+
+	return nil
+}
+
+// Ritorna true se esiste almeno una variabile con il prefisso indicato.
+func hasEnvPrefix(env map[string]string, prefix string) bool {
+	for name := range env {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ritorna il tipo puntato se t è un puntatore.
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// Ritorna il valore puntato se v è un puntatore, allocandolo se nil.
+func allocValue(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Pointer {
+		return v
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return v.Elem()
+}
@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("MYAPP_MAIN_PARAMINT", "13")
+	t.Setenv("myapp_main_paramString", "env")
+	t.Setenv("MYAPP_USERS", `[{"name": "Foo", "email": "foo@email"}]`)
+	t.Setenv("OTHER_MAIN_PARAMINT", "99")
+
+	data := defaultSettings()
+
+	err := LoadEnv("myapp", &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !data.Main.ParamBool {
+		t.Fatal("defaults non persistono")
+	}
+	if data.Main.ParamInt != 13 || data.Main.ParamString != "env" {
+		t.Fatal("override non settati")
+	}
+	if len(data.Users) != 1 || data.Users[0].EMail != "foo@email" {
+		t.Fatal("override json non settati")
+	}
+
+	t.Setenv("MYAPP_MAIN_PARAMBOOL", "maybe")
+
+	err = LoadEnv("myapp", &data)
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+type envPointerSettings struct {
+	*EnvEmbedded
+	Main *settingsMain
+}
+
+type EnvEmbedded struct {
+	Level int
+}
+
+func TestLoadEnvNumbersAndPointers(t *testing.T) {
+	t.Setenv("ENVPTR_MAIN_UNKNOWN", "1")
+
+	var data envPointerSettings
+
+	err := LoadEnv("envptr", &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.EnvEmbedded != nil || data.Main != nil {
+		t.Fatalf("struct allocate senza variabili applicate: %+v", data)
+	}
+
+	// Zeri iniziali: base 10, come negli altri formati.
+	t.Setenv("ENVPTR_MAIN_PARAMINT", "010")
+	t.Setenv("ENVPTR_LEVEL", "08")
+
+	err = LoadEnv("envptr", &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main == nil || data.Main.ParamInt != 10 || data.EnvEmbedded == nil || data.Level != 8 {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	var settings MySettings
+	err = LoadReader(strings.NewReader("MAIN_PARAMINT=010\n"), "env", &settings)
+	if err != nil || settings.Main.ParamInt != 10 {
+		t.Fatalf("dotenv: unexpected value %d, %v", settings.Main.ParamInt, err)
+	}
+}
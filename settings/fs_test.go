@@ -0,0 +1,158 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/app.toml":     {Data: []byte("include = 'common'\n\n[main]\nparamint = 13\n")},
+		"conf/common.jsonc": {Data: []byte(`{ "main": { "paramstring": "common", "paramint": 1 } }`)},
+	}
+
+	data := defaultSettings()
+
+	loaded, err := LoadFS(fsys, "conf/app", &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded != "conf/app.toml" {
+		t.Fatalf("unexpected loaded file %s", loaded)
+	}
+	if data.Main.ParamInt != 13 || data.Main.ParamString != "common" || !data.Main.ParamBool {
+		t.Fatalf("unexpected values %+v", data.Main)
+	}
+
+	loaded, err = LoadFS(fsys, "conf/missing", &data, false)
+	if err != nil || loaded != "" {
+		t.Fatal("missing file not ignored")
+	}
+
+	_, err = LoadFS(fsys, "conf/missing", &data, true)
+	if err == nil {
+		t.Fatal("expected not found error")
+	}
+}
+
+func TestLoadReader(t *testing.T) {
+	data := defaultSettings()
+
+	err := LoadReader(strings.NewReader("main:\n  paramint: 13\n"), "yaml", &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamInt != 13 || !data.Main.ParamBool {
+		t.Fatalf("unexpected values %+v", data.Main)
+	}
+
+	err = LoadReader(strings.NewReader(`{ "main": { "paramstring": "stdin" } }`), ".jsonc", &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamInt != 13 || data.Main.ParamString != "stdin" {
+		t.Fatalf("layering not applied %+v", data.Main)
+	}
+
+	err = LoadReader(strings.NewReader(""), "xml", &data)
+	if err == nil {
+		t.Fatal("expected unknown format error")
+	}
+}
+
+func TestLoadDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"myapp":    "# credenziale\n[main]\nparamint = 13\n",
+		"yamlconf": "---\nmain:\n  paramstring: yaml\n",
+		"jsonconf": "// commento\n{ \"main\": { \"paramfloat\": 2.5 } }",
+		"invalid":  "main:\n\tparamint: 1\n",
+	})
+
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	data := defaultSettings()
+
+	loaded, err := LoadSystemdCredentials("myapp.toml", &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != filepath.Join(dir, "myapp") || data.Main.ParamInt != 13 {
+		t.Fatalf("credential not loaded: %s %+v", loaded, data.Main)
+	}
+
+	_, err = LoadFile(filepath.Join(dir, "yamlconf"), &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadFile(filepath.Join(dir, "jsonconf"), &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamString != "yaml" || data.Main.ParamFloat != 2.5 || data.Main.ParamInt != 13 {
+		t.Fatalf("unexpected values %+v", data.Main)
+	}
+
+	// Documento non valido: l'errore è quello del parser del formato presunto.
+	_, err = LoadFile(filepath.Join(dir, "invalid"), &data, true)
+	if err == nil || !strings.Contains(err.Error(), "yaml") {
+		t.Fatalf("expected yaml error, got %v", err)
+	}
+
+	err = LoadReader(strings.NewReader("main:\n  paramint: 7\n"), "", &data)
+	if err != nil || data.Main.ParamInt != 7 {
+		t.Fatalf("format not detected: %v", err)
+	}
+}
+
+func TestLoadBaseNameNoDetect(t *testing.T) {
+	const app = "mu-config-detect-test"
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"README": "Istruzioni: vedi il manuale.\n",
+	})
+	err := os.WriteFile(filepath.Join(root, app), []byte("\x7fELF\x02\x01\x01\x00"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(root, "xdg"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "home"))
+
+	currDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(currDir)
+
+	data := defaultSettings()
+
+	// Nomi semplici: i file omonimi privi di estensione non vengono caricati.
+	for _, name := range []string{app, "README"} {
+		loaded, err := LoadFile(name, &data, false)
+		if err != nil || loaded != "" {
+			t.Fatalf("%s: unexpected load %q, %v", name, loaded, err)
+		}
+	}
+
+	loadedFilenames, err := LoadFromSearchPath(app, &data)
+	if err != nil || len(loadedFilenames) != 0 {
+		t.Fatalf("unexpected load %v, %v", loadedFilenames, err)
+	}
+
+	// Percorso esplicito: il formato viene rilevato dal contenuto.
+	_, err = LoadFile("./README", &data, false)
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+}
@@ -0,0 +1,104 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+
+		err := os.MkdirAll(filepath.Dir(filename), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.WriteFile(filename, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"main.toml": `
+include = ["common", "secrets/*.yaml"]
+
+[main]
+paramint = 13
+`,
+		"common.jsonc": `{
+	// Valori comuni.
+	"Main": { "ParamInt": 1, "ParamString": "common" }
+}`,
+		"secrets/a.yaml": "main:\n  paramstring: secret\n",
+		"secrets/b.yaml": "include: ../leaf.jsonc\nmain:\n  paramfloat: 2.5\n",
+		"secrets/c.txt":  "ignored",
+		"leaf.jsonc":     `{ "main": { "parambool": false } }`,
+	})
+
+	data := defaultSettings()
+
+	_, err := LoadFile(filepath.Join(dir, "main.toml"), &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Main.ParamInt != 13 || data.Main.ParamString != "secret" ||
+		data.Main.ParamFloat != 2.5 || data.Main.ParamBool {
+		t.Fatalf("include non applicati: %+v", data.Main)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"leaf.jsonc": `{ "include": "main.toml" }`,
+	})
+
+	_, err = LoadFile(filepath.Join(dir, "main.toml"), &data, true)
+	if err == nil || !strings.Contains(err.Error(), "include cycle") ||
+		!strings.Contains(err.Error(), "b.yaml -> "+filepath.Join(dir, "leaf.jsonc")) {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"leaf.jsonc": `{ "include": "missing.toml" }`,
+	})
+
+	_, err = LoadFile(filepath.Join(dir, "main.toml"), &data, true)
+	if err == nil || !strings.Contains(err.Error(), "included file not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"app.ini": "include = common, \"secrets/a.yaml\"\n\n[main]\nparamint = 14\n",
+	})
+
+	data = defaultSettings()
+
+	_, err = LoadFile(filepath.Join(dir, "app.ini"), &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamInt != 14 || data.Main.ParamString != "secret" {
+		t.Fatalf("include non applicati: %+v", data.Main)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"app.hcl": "include = [\"common\", \"secrets/a.yaml\"]\n\nmain {\n  paramint = 15\n}\n",
+	})
+
+	data = defaultSettings()
+
+	_, err = LoadFile(filepath.Join(dir, "app.hcl"), &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamInt != 15 || data.Main.ParamString != "secret" {
+		t.Fatalf("include non applicati: %+v", data.Main)
+	}
+}
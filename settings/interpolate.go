@@ -0,0 +1,229 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Risolve i riferimenti presenti nei valori stringa della configurazione:
+//   - ${NAME}: valore del campo di configurazione con percorso NAME
+//     (livelli separati da '.', indici degli array tra '[]', case insensitive, es. ${main.paramString}),
+//     se esistente, altrimenti della variabile d'ambiente NAME (es. ${HOME});
+//   - ${NAME:-default}: come sopra, ma se il valore è vuoto o non definito usa default,
+//     che può a sua volta contenere riferimenti;
+//   - $${: sequenza di escape per ottenere il testo letterale "${".
+//
+// Ritorna errore per riferimenti non definiti o circolari.
+//   - cfg: PUNTATORE a struttura configurazione.
+func Interpolate(cfg interface{}) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("config data must be a pointer")
+	}
+
+	in := &interpolator{fields: make(map[string]*interpField)}
+	in.collect(v.Elem(), "")
+
+	for _, path := range in.order {
+		_, err := in.resolve(in.fields[path], nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Campo scalare della configurazione, referenziabile.
+type interpField struct {
+	path     string
+	value    string
+	isString bool
+	set      func(string) // Imposta il valore risolto (solo stringhe).
+
+	resolving bool
+	resolved  bool
+}
+
+type interpolator struct {
+	fields map[string]*interpField // Per percorso in minuscolo.
+	order  []string
+}
+
+// Raccoglie ricorsivamente i campi scalari.
+func (in *interpolator) collect(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			in.collect(v.Elem(), path)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinPath(path, field.Name)
+			}
+			in.collect(v.Field(i), fieldPath)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			in.collect(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			keyPath := joinPath(path, fmt.Sprint(key.Interface()))
+
+			if iter.Value().Kind() == reflect.String {
+				m := v
+				in.add(&interpField{
+					path:     keyPath,
+					value:    iter.Value().String(),
+					isString: true,
+					set: func(s string) {
+						m.SetMapIndex(key, reflect.ValueOf(s).Convert(m.Type().Elem()))
+					},
+				})
+				continue
+			}
+
+			// Valori non indirizzabili: referenziabili ma non interpolati.
+			in.collect(iter.Value(), keyPath)
+		}
+
+	case reflect.String:
+		f := &interpField{path: path, value: v.String(), isString: true}
+		if v.CanSet() {
+			f.set = v.SetString
+		}
+		in.add(f)
+
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		in.add(&interpField{path: path, value: fmt.Sprint(v.Interface()), resolved: true})
+	}
+}
+
+func (in *interpolator) add(f *interpField) {
+	key := strings.ToLower(f.path)
+	in.fields[key] = f
+	if f.isString {
+		in.order = append(in.order, key)
+	}
+}
+
+// Risolve i riferimenti del campo, ritornandone il valore finale.
+//   - chain: catena dei campi in corso di risoluzione, per individuare i cicli.
+func (in *interpolator) resolve(f *interpField, chain []string) (string, error) {
+	if f.resolved {
+		return f.value, nil
+	}
+
+	chain = append(chain, f.path)
+	if f.resolving {
+		return "", fmt.Errorf("interpolation cycle: %s", strings.Join(chain, " -> "))
+	}
+
+	f.resolving = true
+	value, err := in.expand(f.value, f.path, chain)
+	f.resolving = false
+	if err != nil {
+		return "", err
+	}
+
+	f.value = value
+	f.resolved = true
+	if f.set != nil {
+		f.set(value)
+	}
+
+	return value, nil
+}
+
+// Espande i riferimenti presenti in s.
+func (in *interpolator) expand(s string, path string, chain []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var builder strings.Builder
+
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			builder.WriteString("${")
+			i += 3
+			continue
+		}
+
+		if !strings.HasPrefix(s[i:], "${") {
+			builder.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		// Cerca la graffa di chiusura, tenendo conto dei riferimenti annidati nel default.
+		end := -1
+		depth := 0
+		for j := i + 2; j < len(s); j++ {
+			if strings.HasPrefix(s[j:], "${") {
+				depth++
+				j++
+			} else if s[j] == '}' {
+				if depth == 0 {
+					end = j
+					break
+				}
+				depth--
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("%s: unterminated reference in %q", path, s)
+		}
+
+		name, def, hasDefault := strings.Cut(s[i+2:end], ":-")
+
+		value, found, err := in.lookup(name, chain)
+		if err != nil {
+			return "", err
+		}
+
+		if hasDefault && value == "" {
+			value, err = in.expand(def, path, chain)
+			if err != nil {
+				return "", err
+			}
+		} else if !found {
+			return "", fmt.Errorf("%s: undefined reference ${%s}", path, name)
+		}
+
+		builder.WriteString(value)
+		i = end + 1
+	}
+
+	return builder.String(), nil
+}
+
+// Ritorna il valore del campo di configurazione o, in sua assenza, della variabile d'ambiente.
+func (in *interpolator) lookup(name string, chain []string) (value string, found bool, err error) {
+	if f, ok := in.fields[strings.ToLower(name)]; ok {
+		value, err = in.resolve(f, chain)
+		return value, true, err
+	}
+
+	value, found = os.LookupEnv(name)
+	return value, found, nil
+}
@@ -0,0 +1,45 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("INTERP_HOME", "/home/foo")
+
+	data := defaultSettings()
+	data.Main.ParamString = "${INTERP_HOME}/data"
+	data.Users[0].Name = "${main.paramString}/sub-${main.paramint}"
+	data.Users[0].EMail = "$${literal} ${INTERP_UNSET:-${users[1].name}}"
+
+	err := Interpolate(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Main.ParamString != "/home/foo/data" {
+		t.Fatal("env mismatch: " + data.Main.ParamString)
+	}
+	if data.Users[0].Name != "/home/foo/data/sub-12" {
+		t.Fatal("config mismatch: " + data.Users[0].Name)
+	}
+	if data.Users[0].EMail != "${literal} Smith" {
+		t.Fatal("default mismatch: " + data.Users[0].EMail)
+	}
+
+	data.Users[0].Name = "${users[1].name}"
+	data.Users[1].Name = "${users[0].name}"
+
+	err = Interpolate(&data)
+	if err == nil || !strings.Contains(err.Error(), "interpolation cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+
+	data.Users[1].Name = "${INTERP_UNSET}"
+
+	err = Interpolate(&data)
+	if err == nil || !strings.Contains(err.Error(), "undefined reference") {
+		t.Fatalf("expected undefined error, got %v", err)
+	}
+}
@@ -0,0 +1,417 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// Sorgente di configurazione, applicabile da un Loader.
+type Source interface {
+	// Descrizione della sorgente, usata nei report.
+	String() string
+
+	// Applica la sorgente alla configurazione.
+	//   - cfg: PUNTATORE a struttura configurazione da popolare.
+	//
+	// Ritorna l'origine effettiva dei dati (ad es. il nome file caricato),
+	// oppure stringa vuota se la sorgente non è disponibile e viene quindi saltata.
+	Load(cfg interface{}) (origin string, err error)
+}
+
+// Sorgente che carica uno o più file, ad es. una directory drop-in o un file con inclusioni.
+// I file vengono riportati in SourceResult: Loader.Watch li osserva e Loader.Origin
+// ne indica il file e la riga da cui proviene ciascun valore.
+type MultiFileSource interface {
+	Source
+
+	// Come Load, ritornando anche i file caricati (compresi gli inclusi) in ordine di applicazione,
+	// e le directory il cui contenuto determina i file da caricare, da osservare anche se la sorgente viene saltata.
+	LoadFiles(cfg interface{}) (origin string, files, dirs []string, err error)
+}
+
+// Sorgente che riporta i percorsi dei campi impostati (ad es. variabili d'ambiente e flag):
+// Provenance li attribuisce alla sorgente anche se il valore non è cambiato.
+type keysSource interface {
+	loadKeys(cfg interface{}) (origin string, keys []string, err error)
+}
+
+// Esito del caricamento di una sorgente.
+type SourceStatus int
+
+const (
+	SourceLoaded  SourceStatus = iota // Sorgente trovata e applicata.
+	SourceSkipped                     // Sorgente non disponibile.
+	SourceFailed                      // Errore durante l'applicazione della sorgente.
+)
+
+func (s SourceStatus) String() string {
+	switch s {
+	case SourceLoaded:
+		return "loaded"
+	case SourceSkipped:
+		return "skipped"
+	case SourceFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Report del caricamento di una singola sorgente.
+type SourceResult struct {
+	Source Source
+	Status SourceStatus
+	Origin string   // Origine effettiva dei dati, se caricata.
+	Files  []string // File caricati, in ordine di applicazione (vedi MultiFileSource).
+	Dirs   []string // Directory osservate da Loader.Watch (vedi MultiFileSource).
+	Err    error    // Errore, se fallita.
+}
+
+// Loader applica in ordine una lista di sorgenti alla medesima configurazione,
+// ciascuna a mo' di override delle precedenti.
+type Loader struct {
+	sources    []Source
+	provenance atomic.Value // *Provenance dell'ultimo Load, sostituita al suo termine.
+}
+
+// Crea un loader con le sorgenti indicate, in ordine di applicazione.
+func NewLoader(sources ...Source) *Loader {
+	return &Loader{
+		sources: sources,
+	}
+}
+
+// Ritorna l'origine del valore del campo indicato, al termine dell'ultimo Load.
+//   - path: percorso del campo, ad es. "Main.ParamInt" o "Users[1].Name"; case insensitive.
+func (l *Loader) Origin(path string) (Origin, bool) {
+	provenance, _ := l.provenance.Load().(*Provenance)
+	if provenance == nil {
+		return Origin{}, false
+	}
+	return provenance.Origin(path)
+}
+
+// Ritorna l'elenco dei campi caricati dall'ultimo Load, con valore e relativa origine.
+// I valori sono quelli successivi all'interpolazione (vedi Interpolate) e precedenti
+// alla risoluzione dei secret, che non vengono quindi mai riportati.
+func (l *Loader) Explain() string {
+	provenance, _ := l.provenance.Load().(*Provenance)
+	if provenance == nil {
+		return ""
+	}
+	return provenance.Explain()
+}
+
+// Accoda una sorgente.
+func (l *Loader) Add(source Source) *Loader {
+	l.sources = append(l.sources, source)
+	return l
+}
+
+// Applica in ordine le sorgenti alla configurazione.
+//   - cfg: PUNTATORE a struttura configurazione da popolare.
+//
+// Si interrompe alla prima sorgente fallita;
+// ritorna comunque il report delle sorgenti fin qui elaborate.
+// Al termine risolve i riferimenti nei valori stringa (vedi Interpolate) e i secret (vedi ResolveSecrets),
+// quindi valida la configurazione risultante (vedi Validate).
+func (l *Loader) Load(cfg interface{}) ([]SourceResult, error) {
+	results := make([]SourceResult, 0, len(l.sources))
+
+	provenance, err := newProvenance(cfg, "initial value")
+	if err != nil {
+		return results, err
+	}
+	// Pubblicata solo al termine: Origin ed Explain possono essere invocate in concorrenza (vedi Watch).
+	defer l.provenance.Store(provenance)
+
+	for _, source := range l.sources {
+		var origin string
+		var files, dirs, keys []string
+
+		switch s := source.(type) {
+		case MultiFileSource:
+			origin, files, dirs, err = s.LoadFiles(cfg)
+		case keysSource:
+			origin, keys, err = s.loadKeys(cfg)
+		default:
+			origin, err = source.Load(cfg)
+		}
+		if err == nil && origin != "" {
+			err = provenance.update(cfg, source, origin, files, keys)
+		}
+
+		result := SourceResult{
+			Source: source,
+			Origin: origin,
+			Files:  files,
+			Dirs:   dirs,
+			Err:    err,
+		}
+
+		switch {
+		case err != nil:
+			result.Status = SourceFailed
+		case origin == "":
+			result.Status = SourceSkipped
+		default:
+			result.Status = SourceLoaded
+		}
+
+		results = append(results, result)
+
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	err = Interpolate(cfg)
+	if err != nil {
+		return results, err
+	}
+
+	// Explain riporta i valori interpolati, ma non i secret risolti.
+	err = provenance.refresh(cfg)
+	if err != nil {
+		return results, err
+	}
+
+	err = ResolveSecrets(cfg)
+	if err != nil {
+		return results, err
+	}
+
+	return results, Validate(cfg)
+}
+
+type defaultsSource struct {
+	defaults interface{}
+}
+
+// Sorgente che reimposta la configurazione a una copia dei valori di default.
+//   - defaults: struttura (o puntatore a struttura) con i valori di default.
+func DefaultsSource(defaults interface{}) Source {
+	return &defaultsSource{defaults: defaults}
+}
+
+func (s *defaultsSource) String() string {
+	return "defaults"
+}
+
+func (s *defaultsSource) Load(cfg interface{}) (string, error) {
+	if s.defaults == nil {
+		return "", nil
+	}
+
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return "", errors.New("config data must be a pointer")
+	}
+
+	// Azzera la destinazione e vi copia i default, senza condividere slice e map.
+	v.Elem().Set(reflect.Zero(v.Elem().Type()))
+
+	err := cloneData(s.defaults, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	return "defaults", nil
+}
+
+type fileSource struct {
+	filename string
+	required bool
+}
+
+// Sorgente da file, vedi LoadFile.
+//   - required: true per fallire se il file non viene trovato.
+func FileSource(filename string, required bool) Source {
+	return &fileSource{filename: filename, required: required}
+}
+
+func (s *fileSource) String() string {
+	return "file " + s.filename
+}
+
+func (s *fileSource) Load(cfg interface{}) (string, error) {
+	origin, _, _, err := s.LoadFiles(cfg)
+	return origin, err
+}
+
+func (s *fileSource) LoadFiles(cfg interface{}) (string, []string, []string, error) {
+	loadedFilename, included, err := loadFileIncludes(s.filename, cfg, s.required)
+	if err != nil || loadedFilename == "" {
+		return "", nil, nil, err
+	}
+
+	return loadedFilename, append(included, loadedFilename), nil, nil
+}
+
+type fsSource struct {
+	fsys     fs.FS
+	filename string
+	required bool
+}
+
+// Sorgente da file system astratto (ad es. embed.FS), vedi LoadFS.
+//   - required: true per fallire se il file non viene trovato.
+func FSSource(fsys fs.FS, filename string, required bool) Source {
+	return &fsSource{fsys: fsys, filename: filename, required: required}
+}
+
+func (s *fsSource) String() string {
+	return "fs " + s.filename
+}
+
+func (s *fsSource) Load(cfg interface{}) (string, error) {
+	return LoadFS(s.fsys, s.filename, cfg, s.required)
+}
+
+type dirSource struct {
+	dir      string
+	required bool
+}
+
+// Sorgente da directory di file drop-in, vedi LoadDir.
+//   - required: true per fallire se la directory non viene trovata.
+func DirSource(dir string, required bool) Source {
+	return &dirSource{dir: dir, required: required}
+}
+
+func (s *dirSource) String() string {
+	return "dir " + s.dir
+}
+
+func (s *dirSource) Load(cfg interface{}) (string, error) {
+	origin, _, _, err := s.LoadFiles(cfg)
+	return origin, err
+}
+
+// Osserva anche la directory, per ricaricare la configurazione all'aggiunta o rimozione di file.
+func (s *dirSource) LoadFiles(cfg interface{}) (string, []string, []string, error) {
+	fullpathDir, err := GetFileFullPath(s.dir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	dirs := []string{fullpathDir}
+
+	loadedFilenames, files, err := loadDir(fullpathDir, cfg, s.required)
+	if err != nil || len(loadedFilenames) == 0 {
+		return "", nil, dirs, err
+	}
+
+	return strings.Join(loadedFilenames, ", "), files, dirs, nil
+}
+
+type systemdCredentialsSource struct {
+	filename string
+	required bool
+}
+
+// Sorgente da credenziali Systemd, vedi LoadSystemdCredentials.
+//   - required: true per fallire se la credenziale non viene trovata.
+func SystemdCredentialsSource(filename string, required bool) Source {
+	return &systemdCredentialsSource{filename: filename, required: required}
+}
+
+func (s *systemdCredentialsSource) String() string {
+	return "systemd credentials " + s.filename
+}
+
+func (s *systemdCredentialsSource) Load(cfg interface{}) (string, error) {
+	origin, _, _, err := s.LoadFiles(cfg)
+	return origin, err
+}
+
+func (s *systemdCredentialsSource) LoadFiles(cfg interface{}) (string, []string, []string, error) {
+	loadedFilename, included, err := loadSystemdCredentials(s.filename, cfg, s.required)
+	if err != nil || loadedFilename == "" {
+		return "", nil, nil, err
+	}
+
+	return loadedFilename, append(included, loadedFilename), nil, nil
+}
+
+type envSource struct {
+	prefix string
+}
+
+// Sorgente da variabili d'ambiente, vedi LoadEnv.
+func EnvSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+func (s *envSource) String() string {
+	return "env " + s.prefix
+}
+
+func (s *envSource) Load(cfg interface{}) (string, error) {
+	origin, _, err := s.loadKeys(cfg)
+	return origin, err
+}
+
+func (s *envSource) loadKeys(cfg interface{}) (string, []string, error) {
+	applied, err := loadEnv(environ(), s.prefix, cfg)
+	if err != nil || len(applied) == 0 {
+		return "", nil, err
+	}
+
+	return "env " + s.prefix, applied, nil
+}
+
+type flagsSource struct {
+	flags *flag.FlagSet
+}
+
+// Sorgente da flag della riga di comando.
+// Vengono applicati i soli flag impostati esplicitamente il cui nome corrisponde
+// al percorso di un campo, con i livelli separati da '.', ad es. -main.paramint=13;
+// i nomi sono case insensitive. Gli altri flag vengono ignorati.
+//   - flags: flag set già parsato; se nil usa flag.CommandLine.
+func FlagsSource(flags *flag.FlagSet) Source {
+	if flags == nil {
+		flags = flag.CommandLine
+	}
+	return &flagsSource{flags: flags}
+}
+
+func (s *flagsSource) String() string {
+	return "flags"
+}
+
+func (s *flagsSource) Load(cfg interface{}) (string, error) {
+	origin, _, err := s.loadKeys(cfg)
+	return origin, err
+}
+
+func (s *flagsSource) loadKeys(cfg interface{}) (string, []string, error) {
+	vars := make(map[string]string)
+	s.flags.Visit(func(f *flag.Flag) {
+		name := strings.ToUpper(strings.ReplaceAll(f.Name, ".", "_"))
+		vars[name] = f.Value.String()
+	})
+
+	applied, err := loadEnv(vars, "", cfg)
+	if err != nil || len(applied) == 0 {
+		return "", nil, err
+	}
+
+	return "flags", applied, nil
+}
+
+// Copia in profondità i dati di from in to (PUNTATORE).
+func cloneData(from, to interface{}) error {
+	bout, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bout, to)
+}
@@ -0,0 +1,71 @@
+package settings
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("main:\n  paramint: 13\n  paramstring: file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	t.Setenv("LOADERTEST_MAIN_PARAMSTRING", "env")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Int("main.paramint", 0, "")
+	flags.Bool("verbose", false, "")
+	err = flags.Parse([]string{"-main.paramint=14", "-verbose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data MySettings
+
+	loader := NewLoader(
+		DefaultsSource(defaultSettings()),
+		FileSource(filepath.Join(dir, "base"), true),
+		FileSource(filepath.Join(dir, "missing"), false),
+		SystemdCredentialsSource("base", false),
+		EnvSource("loadertest"),
+		FlagsSource(flags),
+	)
+
+	results, err := loader.Load(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := []SourceStatus{SourceLoaded, SourceLoaded, SourceSkipped, SourceSkipped, SourceLoaded, SourceLoaded}
+	if len(results) != len(statuses) {
+		t.Fatalf("unexpected results count %d", len(results))
+	}
+	for i, result := range results {
+		if result.Status != statuses[i] {
+			t.Fatalf("%s: got %s, want %s", result.Source, result.Status, statuses[i])
+		}
+	}
+
+	if !data.Main.ParamBool || len(data.Users) != 2 {
+		t.Fatal("defaults non persistono")
+	}
+	if data.Main.ParamInt != 14 || data.Main.ParamString != "env" {
+		t.Fatal("override non settati")
+	}
+
+	loader.Add(FileSource(filepath.Join(dir, "missing"), true))
+
+	results, err = loader.Load(&data)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if results[len(results)-1].Status != SourceFailed {
+		t.Fatal("expected failed source")
+	}
+}
@@ -0,0 +1,272 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Errore di parsing di un file di configurazione, comune a tutti i formati.
+type ParseError struct {
+	File    string // Nome del file.
+	Line    int    // Riga (a partire da 1), 0 se non disponibile.
+	Column  int    // Colonna (a partire da 1), 0 se non disponibile.
+	Key     string // Percorso della chiave, se disponibile (es. "main.paramInt").
+	Snippet string // Estratto del file con l'indicazione della posizione dell'errore.
+	Err     error  // Errore originale del decoder.
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+
+	b.WriteString("cannot parse ")
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&b, ":%d", e.Column)
+		}
+	}
+	b.WriteString(": ")
+
+	if e.Key != "" {
+		b.WriteString(e.Key)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+
+	if e.Snippet != "" {
+		b.WriteString("\n")
+		b.WriteString(e.Snippet)
+	}
+
+	return b.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// Ritorna l'errore di parsing del file, individuandone la posizione.
+//   - ext: formato del file.
+//   - src: contenuto originale del file.
+//   - decoded: dati effettivamente decodificati, che differiscono da src
+//     se il documento è stato riscritto (ad es. per la rimozione della direttiva di inclusione).
+//   - cfg: struttura configurazione destinataria, per individuare le chiavi sconosciute; nil se non disponibile.
+func newParseError(filename, ext string, src, decoded []byte, cfg interface{}, err error) *ParseError {
+	e := &ParseError{File: filename, Err: err}
+
+	// Chiave sconosciuta: se ne individua la posizione e si suggerisce quella valida più simile.
+	if name, ok := unknownFieldName(err); ok && cfg != nil {
+		// Le variabili Dotenv sono case insensitive e per convenzione in maiuscolo:
+		// la chiave viene riportata in minuscolo, come i percorsi dei campi.
+		if ext == ".env" {
+			name = strings.ToLower(name)
+		}
+		path, line, ukErr := findUnknownKey(reflect.TypeOf(cfg), keyLines(ext, src), name, err)
+		if ukErr != nil {
+			e.Err = ukErr
+			e.Key = path
+			e.Line = line
+			e.Column = keyColumn(src, line, ukErr.Key)
+			e.Snippet = snippet(src, e.Line, e.Column)
+			return e
+		}
+	}
+
+	offset := int64(-1)
+	yamlSyntax := false
+
+	var jsonSyntaxErr *json.SyntaxError
+	var jsonTypeErr *json.UnmarshalTypeError
+	var syntaxErr *parsers.SyntaxError
+	var valueErr *parsers.ValueError
+	var tomlErr *toml.DecodeError
+	var tomlStrictErr *toml.StrictMissingError
+	var yamlErr *yaml.TypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+
+	case errors.As(err, &valueErr):
+		e.Key = valueErr.Key
+
+	case errors.As(err, &jsonSyntaxErr):
+		offset = jsonSyntaxErr.Offset - 1
+
+	case errors.As(err, &jsonTypeErr):
+		offset = jsonTypeErr.Offset - 1
+		e.Key = lowerPath(jsonTypeErr.Field)
+
+	case errors.As(err, &tomlErr):
+		e.Line, e.Column = tomlErr.Position()
+		e.Key = strings.Join(tomlErr.Key(), ".")
+
+	case errors.As(err, &tomlStrictErr) && len(tomlStrictErr.Errors) > 0:
+		e.Line, e.Column = tomlStrictErr.Errors[0].Position()
+		e.Key = strings.Join(tomlStrictErr.Errors[0].Key(), ".")
+
+	case errors.As(err, &yamlErr) && len(yamlErr.Errors) > 0:
+		e.Line = yamlLine(yamlErr.Errors[0])
+
+	case ext == ".yaml":
+		// Errore di sintassi: la riga indicata è quella del contesto, non della chiave.
+		e.Line = yamlLine(err.Error())
+		yamlSyntax = true
+	}
+
+	if offset >= 0 {
+		e.Line, e.Column = offsetPosition(decoded, offset)
+	}
+
+	// Le posizioni nel documento riscritto non corrispondono al file: si cerca la chiave.
+	if !bytes.Equal(src, decoded) {
+		e.Line, e.Column = 0, 0
+	}
+	if e.Line == 0 && e.Key != "" {
+		e.Line, e.Column = keyPosition(ext, src, e.Key)
+	}
+	if e.Key == "" && e.Line > 0 && !yamlSyntax {
+		e.Key = lineKey(ext, src, e.Line)
+	}
+
+	e.Snippet = snippet(src, e.Line, e.Column)
+
+	return e
+}
+
+// Converte un percorso Go (es. "Main.ParamInt") nella forma usata negli errori (es. "main.paramInt").
+func lowerPath(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	parts := strings.Split(path, ".")
+	for i := range parts {
+		parts[i] = lowerFirst(parts[i])
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func yamlLine(msg string) int {
+	m := yamlLineRegexp.FindStringSubmatch(msg)
+	if m == nil {
+		return 0
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
+
+// Ritorna riga e colonna (a partire da 1, in caratteri) dell'offset in byte.
+func offsetPosition(bb []byte, offset int64) (line, column int) {
+	if offset > int64(len(bb)) {
+		offset = int64(len(bb))
+	}
+
+	before := bb[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+
+	return line, column
+}
+
+// Ritorna la posizione della chiave nel documento, 0 se non individuabile.
+func keyPosition(ext string, bb []byte, key string) (line, column int) {
+	line = keyLines(ext, bb)[strings.ToLower(key)]
+	if line == 0 {
+		return 0, 0
+	}
+
+	return line, keyColumn(bb, line, key[strings.LastIndexAny(key, ".]")+1:])
+}
+
+// Ritorna la colonna del nome della chiave nella riga indicata, 0 se non individuabile.
+func keyColumn(bb []byte, line int, name string) int {
+	text := strings.ToLower(string(sourceLine(bb, line)))
+	name = strings.ToLower(name)
+
+	i := strings.Index(text, name)
+	if name == "" || i < 0 {
+		return 0
+	}
+
+	return utf8.RuneCountInString(text[:i]) + 1
+}
+
+// Ritorna la chiave più annidata definita alla riga indicata (in minuscolo), stringa vuota se assente.
+func lineKey(ext string, bb []byte, line int) string {
+	key := ""
+
+	for k, l := range keyLines(ext, bb) {
+		if l == line && (len(k) > len(key) || len(k) == len(key) && k < key) {
+			key = k
+		}
+	}
+
+	return key
+}
+
+// Ritorna la riga indicata (a partire da 1) del documento, nil se inesistente.
+func sourceLine(bb []byte, line int) []byte {
+	lines := bytes.Split(bb, []byte("\n"))
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+
+	return bytes.TrimRight(lines[line-1], "\r")
+}
+
+// Ritorna l'estratto della riga indicata con un cursore alla colonna,
+// ad es.:
+//
+//	3 |   paramInt = "abc"
+//	  |              ^
+func snippet(bb []byte, line, column int) string {
+	text := sourceLine(bb, line)
+	if text == nil {
+		return ""
+	}
+
+	prefix := fmt.Sprintf("%4d | ", line)
+	s := prefix + string(text)
+
+	if column > 0 {
+		var caret strings.Builder
+		caret.WriteString(strings.Repeat(" ", len(prefix)-2))
+		caret.WriteString("| ")
+
+		// Mantiene le tabulazioni, per allineare il cursore al testo.
+		i := 1
+		for _, r := range string(text) {
+			if i >= column {
+				break
+			}
+			if r == '\t' {
+				caret.WriteRune('\t')
+			} else {
+				caret.WriteRune(' ')
+			}
+			i++
+		}
+		caret.WriteRune('^')
+
+		s += "\n" + caret.String()
+	}
+
+	return s
+}
@@ -0,0 +1,128 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"type.jsonc":      "{\n\t// commento\n\t\"main\": {\n\t\t/* altro\n\t\t   commento */ \"paramInt\": \"abc\"\n\t}\n}",
+		"syntax.jsonc":    "{\n\t// commento\n\t\"main\": {\n\t\t\"paramInt\" 1\n\t}\n}",
+		"syntax.json5":    "{\n\tmain: { paramString: hello },\n}\n",
+		"type.toml":       "# commento\n[main]\nparamint = 'abc'\n",
+		"type.yaml":       "main:\n  paramint: abc\n",
+		"syntax.yaml":     "main:\n  paramint: 1\n paramstring: x\n",
+		"include.toml":    "include = 'syntax.yaml'\n",
+		"typeinc.jsonc":   "{\n\t\"include\": [],\n\t\"main\": {\n\t\t\"paramInt\": \"abc\"\n\t}\n}",
+		"type.ini":        "; commento\n[main]\nparamint = abc\n",
+		"syntax.ini":      "[main\nparamint = 1\n",
+		"type.env":        "# commento\nMAIN_PARAMINT=abc\n",
+		"type.hcl":        "# commento\nmain {\n  paramint = \"abc\"\n}\n",
+		"syntax.hcl":      "main {\n  paramint 1\n}\n",
+		"type.properties": "# commento\nmain.paramint = abc\n",
+	})
+
+	tests := []struct {
+		file       string
+		errFile    string
+		line, col  int
+		key        string
+		snippetEnd string
+	}{
+		{"type.jsonc", "type.jsonc", 5, 34, "main.paramInt", "\t\t                               ^"},
+		{"syntax.jsonc", "syntax.jsonc", 4, 14, "", "\t\t           ^"},
+		{"syntax.json5", "syntax.json5", 2, 23, "", "\t                     ^"},
+		{"type.toml", "type.toml", 3, 12, "main.paramint", "           ^"},
+		{"type.yaml", "type.yaml", 2, 0, "main.paramint", "   2 |   paramint: abc"},
+		{"syntax.yaml", "syntax.yaml", 2, 0, "", "   2 |   paramint: 1"},
+		{"include.toml", "syntax.yaml", 2, 0, "", "   2 |   paramint: 1"},
+		{"typeinc.jsonc", "typeinc.jsonc", 4, 4, "main.paramInt", "\t\t ^"},
+		{"type.ini", "type.ini", 3, 1, "main.paramint", "     | ^"},
+		{"syntax.ini", "syntax.ini", 1, 1, "", "     | ^"},
+		{"type.env", "type.env", 2, 6, "main.paramint", "     |      ^"},
+		{"type.hcl", "type.hcl", 3, 3, "main.paramint", "     |   ^"},
+		{"syntax.hcl", "syntax.hcl", 2, 12, "", "     |            ^"},
+		{"type.properties", "type.properties", 2, 6, "main.paramint", "     |      ^"},
+	}
+
+	for _, test := range tests {
+		data := defaultSettings()
+
+		_, err := LoadFile(filepath.Join(dir, test.file), &data, true)
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("%s: expected ParseError, got %v", test.file, err)
+		}
+
+		if perr.File != filepath.Join(dir, test.errFile) || perr.Line != test.line || perr.Column != test.col || perr.Key != test.key {
+			t.Fatalf("%s: unexpected position %s:%d:%d %q", test.file, perr.File, perr.Line, perr.Column, perr.Key)
+		}
+		if !strings.HasSuffix(perr.Snippet, test.snippetEnd) {
+			t.Fatalf("%s: unexpected snippet\n%s", test.file, perr.Snippet)
+		}
+	}
+
+	// Il tipo dell'errore originale è preservato.
+	data := defaultSettings()
+	_, err := LoadFile(filepath.Join(dir, "type.jsonc"), &data, true)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("original error lost: %v", err)
+	}
+}
+
+func TestUnknownKey(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"unknown.jsonc":      "{\n\t\"main\": {\n\t\t\"paramInit\": 1\n\t}\n}",
+		"unknown.toml":       "[main]\nparamint = 1\nparamInit = 2\n",
+		"unknown.yaml":       "users:\n  - name: a\n    emial: x\n",
+		"unknown.ini":        "[users.0]\nname = a\nemial = x\n",
+		"unknown.env":        "MAIN_PARAMINT=1\nMAIN_PARAMINIT=2\n",
+		"unknown.hcl":        "users {\n  name  = \"a\"\n  emial = \"x\"\n}\n",
+		"unknown.properties": "users.0.name=a\nusers.0.emial=x\n",
+	})
+
+	tests := []struct {
+		file       string
+		line       int
+		key        string
+		suggestion string
+	}{
+		{"unknown.jsonc", 3, "main.paramInit", "paramInt"},
+		{"unknown.toml", 3, "main.paramInit", "paramInt"},
+		{"unknown.yaml", 3, "users[0].emial", "eMail"},
+		{"unknown.ini", 3, "users[0].emial", "eMail"},
+		{"unknown.env", 2, "main.paraminit", "paramInt"},
+		{"unknown.hcl", 3, "users[0].emial", "eMail"},
+		{"unknown.properties", 2, "users[0].emial", "eMail"},
+	}
+
+	for _, test := range tests {
+		data := defaultSettings()
+
+		_, err := LoadFile(filepath.Join(dir, test.file), &data, true)
+
+		var perr *ParseError
+		var ukErr *UnknownKeyError
+		if !errors.As(err, &perr) || !errors.As(err, &ukErr) {
+			t.Fatalf("%s: expected UnknownKeyError, got %v", test.file, err)
+		}
+
+		if perr.Line != test.line || perr.Key != test.key || ukErr.Suggestion != test.suggestion {
+			t.Fatalf("%s: unexpected error %v", test.file, err)
+		}
+		if !strings.Contains(err.Error(), "did you mean \""+test.suggestion+"\"?") || len(ukErr.Valid) == 0 {
+			t.Fatalf("%s: unexpected message %v", test.file, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Rileva il formato di un documento dal suo contenuto,
+// per i file privi di estensione (es. credenziali systemd o secret Kubernetes).
+// Ritorna l'estensione corrispondente (".jsonc", ".json5", ".toml", ".yaml"),
+// oppure una stringa vuota se il formato non è riconosciuto.
+func DetectFormat(bb []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(bb, []byte("\xef\xbb\xbf")))
+
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	// Json, eventualmente preceduto da commenti Jsonc; Json5 se valido solo come tale.
+	if trimmed[0] == '{' || bytes.HasPrefix(trimmed, []byte("//")) || bytes.HasPrefix(trimmed, []byte("/*")) {
+		if !json.Valid(translate(trimmed)) {
+			if j, _, err := json5ToJson(trimmed); err == nil && json.Valid(j) {
+				return ".json5"
+			}
+		}
+		return ".jsonc"
+	}
+
+	// Toml e Yaml condividono i commenti '#', ma la sintassi "chiave: valore"
+	// non è Toml valido, mentre "chiave = valore" in Yaml è un semplice scalare.
+	var m map[string]interface{}
+
+	if toml.Unmarshal(trimmed, &m) == nil && len(m) > 0 {
+		return ".toml"
+	}
+
+	m = nil
+	if yaml.Unmarshal(trimmed, &m) == nil && len(m) > 0 {
+		return ".yaml"
+	}
+
+	// Documento non valido: si sceglie in base alla prima riga significativa,
+	// affinché l'errore venga riportato dal parser del formato presunto.
+	for _, line := range bytes.Split(trimmed, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			return ".toml"
+		}
+
+		eq := bytes.IndexByte(line, '=')
+		colon := bytes.IndexByte(line, ':')
+		switch {
+		case eq >= 0 && (colon < 0 || eq < colon):
+			return ".toml"
+		case colon >= 0 || line[0] == '-':
+			return ".yaml"
+		}
+
+		break
+	}
+
+	return ""
+}
@@ -0,0 +1,482 @@
+package parsers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Dotenv (.env): variabili "CHIAVE=valore", eventualmente precedute da "export",
+// i cui nomi corrispondono ai percorsi dei campi separati da '_',
+// ad es. MAIN_PARAMSUB_PARAMARRAY=1,2,3 imposta Main.ParamSub.ParamArray.
+// I nomi sono case insensitive e vengono risolti sul tipo destinatario,
+// per cui anche i campi il cui nome contiene '_' sono individuati correttamente.
+// Gli elementi degli elenchi di strutture sono indicati dall'indice, es. USERS_0_NAME;
+// le chiavi delle mappe sono riportate come scritte, es. MAIN_PARAMMAP_key.
+// I valori possono essere tra apici singoli (letterali) o doppi (con le sequenze di escape Go);
+// i commenti iniziano per '#' ad inizio riga o, nei valori non quotati, preceduti da uno spazio.
+// Per la conversione dei valori vedi flat.go.
+
+func LoadDotenvFile(filename string, data interface{}) error {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return LoadDotenv(bb, data)
+}
+
+func LoadDotenv(bb []byte, data interface{}) error {
+	vars, err := parseDotenv(bb)
+	if err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(data)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return errors.New("data must be a pointer")
+	}
+
+	tree := ordered.NewOrderedMap()
+	for _, v := range vars {
+		path, err := dotenvPath(t, v.name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+
+		err = setFlatPath(tree, path, v.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+	}
+
+	return decodeFlat(tree, data)
+}
+
+func SaveDotenvFile(filename string, data interface{}) error {
+	b, err := SaveDotenv(data)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(filename, b, nil)
+}
+
+// Codifica in Dotenv: nomi dei campi in maiuscolo, chiavi delle mappe come scritte.
+func SaveDotenv(data interface{}) ([]byte, error) {
+	v, err := orderedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(*ordered.OrderedMap)
+	if !ok {
+		return nil, errors.New("dotenv document must be an object")
+	}
+
+	var b bytes.Buffer
+	err = writeDotenv(&b, m, "", reflect.TypeOf(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Chiavi presenti in un documento Dotenv (vedi JsoncKeyLines).
+// Non disponendo del tipo destinatario, ciascun '_' separa un livello:
+// i percorsi sono esatti solo per i campi il cui nome non contiene '_'.
+func DotenvKeyLines(bb []byte) (map[string]int, error) {
+	vars, err := parseDotenv(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]int)
+	for _, v := range vars {
+		path := ""
+		for _, segment := range strings.Split(v.name, "_") {
+			path = joinFlatPath(path, segment)
+		}
+		lines[path] = v.line
+	}
+
+	return lines, nil
+}
+
+// Direttiva di inclusione di un documento Dotenv, come variabile INCLUDE;
+// il valore è un elenco di percorsi separati da virgole.
+// Il documento viene ritornato con la riga della direttiva vuota, mantenendo le righe.
+func DotenvIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	vars, err := parseDotenv(bb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := make(map[int]bool)
+	for _, v := range vars {
+		if !strings.EqualFold(v.name, IncludeKey) {
+			continue
+		}
+
+		for _, item := range splitList(v.value) {
+			includes = append(includes, unquoteValue(item))
+		}
+		lines[v.line] = true
+	}
+	if len(lines) == 0 {
+		return nil, bb, nil
+	}
+
+	rows := bytes.SplitAfter(bb, []byte("\n"))
+	rest = make([]byte, 0, len(bb))
+	for i, row := range rows {
+		if lines[i+1] {
+			row = row[len(bytes.TrimRight(row, "\r\n")):]
+		}
+		rest = append(rest, row...)
+	}
+
+	return includes, rest, nil
+}
+
+// Ritorna il valore Dotenv, tra apici se contiene spazi, apici, '#' o caratteri di controllo.
+// Il valore deve essere già in forma testuale (vedi flatText).
+func DotenvValue(s string) string {
+	quote := false
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(`"'#\$`+"`", r) {
+			quote = true
+			break
+		}
+	}
+
+	switch {
+	case !quote:
+		return s
+	case !strings.ContainsAny(s, "'\n\r"):
+		return "'" + s + "'"
+	}
+
+	return strconv.Quote(s)
+}
+
+// Ritorna la stringa Dotenv, tra apici se necessario (vedi DotenvValue).
+func DotenvString(s string) string {
+	// Le stringhe racchiuse tra apici verrebbero private degli apici durante la conversione (vedi flat.go).
+	if unquoteValue(s) != s {
+		s = FlatString(s)
+	}
+
+	return DotenvValue(s)
+}
+
+// Ritorna il nome Dotenv corrispondente al percorso, in maiuscolo.
+func DotenvKey(path ...string) string {
+	return strings.ToUpper(strings.Join(path, "_"))
+}
+
+type dotenvVar struct {
+	name  string
+	value string // Valore privato degli apici Dotenv (vedi flat.go per gli apici degli elementi di elenchi).
+	line  int
+}
+
+// Legge le variabili del documento, nell'ordine in cui sono definite.
+func parseDotenv(bb []byte) ([]dotenvVar, error) {
+	var vars []dotenvVar
+
+	doc := string(bb)
+	offset := 0
+	if strings.HasPrefix(doc, "\ufeff") {
+		offset = len("\ufeff")
+		doc = doc[offset:]
+	}
+
+	for i, row := range strings.SplitAfter(doc, "\n") {
+		start := offset
+		offset += len(row)
+
+		text := strings.TrimSpace(row)
+		start += strings.Index(row, text)
+
+		errorf := func(format string, args ...interface{}) error {
+			return &SyntaxError{Msg: fmt.Sprintf(format, args...), Offset: int64(start)}
+		}
+
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		if rest := strings.TrimPrefix(text, "export"); rest != text && rest != "" && unicode.IsSpace(rune(rest[0])) {
+			text = strings.TrimSpace(rest)
+		}
+
+		name, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, errorf("expected NAME=value")
+		}
+
+		name = strings.TrimSpace(name)
+		if name == "" || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+			return nil, errorf("invalid variable name %q", name)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+
+		case value[0] == '"' || value[0] == '\'':
+			end := dotenvQuoteEnd(value)
+			if end < 0 {
+				return nil, errorf("unterminated quoted value for %s", name)
+			}
+
+			if trailing := strings.TrimSpace(value[end+1:]); trailing != "" && trailing[0] != '#' {
+				return nil, errorf("unexpected characters after quoted value for %s", name)
+			}
+
+			if value[0] == '\'' {
+				value = value[1:end]
+			} else if u, err := strconv.Unquote(value[:end+1]); err == nil {
+				value = u
+			} else {
+				return nil, errorf("invalid quoted value for %s: %s", name, err)
+			}
+
+		default:
+			// Commento in coda.
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			if i := strings.Index(value, "\t#"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		vars = append(vars, dotenvVar{name: name, value: value, line: i + 1})
+	}
+
+	return vars, nil
+}
+
+// Ritorna la posizione dell'apice di chiusura del valore, -1 se assente.
+func dotenvQuoteEnd(value string) int {
+	quote := value[0]
+
+	for i := 1; i < len(value); i++ {
+		switch {
+		case quote == '"' && value[i] == '\\':
+			i++
+		case value[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Risolve il nome della variabile sul tipo t, ritornando il percorso nell'albero:
+// nomi Json dei campi, chiavi delle mappe, indici degli elementi.
+func dotenvPath(t reflect.Type, name string) ([]string, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := flatFields(t)
+
+		// Campi il cui nome corrisponde alla variabile o ne è un prefisso, dal più lungo.
+		var candidates []reflect.StructField
+		for _, field := range fields {
+			n := len(field.Name)
+			if len(name) == n && strings.EqualFold(name, field.Name) ||
+				len(name) > n && name[n] == '_' && strings.EqualFold(name[:n], field.Name) {
+				candidates = append(candidates, field)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return len(candidates[i].Name) > len(candidates[j].Name)
+		})
+
+		var err error
+		for _, field := range candidates {
+			var path []string
+			path, err = dotenvPath(field.Type, strings.TrimPrefix(name[len(field.Name):], "_"))
+			if err == nil {
+				return append([]string{field.Name}, path...), nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		segment, _, _ := strings.Cut(name, "_")
+		return nil, fmt.Errorf("unknown field %q", segment)
+
+	case reflect.Map:
+		elt := t.Elem()
+		for elt.Kind() == reflect.Pointer {
+			elt = elt.Elem()
+		}
+
+		switch elt.Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			key, rest, _ := strings.Cut(name, "_")
+			path, err := dotenvPath(elt, rest)
+			if err != nil {
+				return nil, err
+			}
+			return append([]string{key}, path...), nil
+		}
+
+		return []string{name}, nil
+
+	case reflect.Slice, reflect.Array:
+		index, rest, _ := strings.Cut(name, "_")
+		if _, err := strconv.Atoi(index); err != nil {
+			return nil, fmt.Errorf("invalid index %q", index)
+		}
+
+		path, err := dotenvPath(t.Elem(), rest)
+		if err != nil {
+			return nil, err
+		}
+		return append([]string{index}, path...), nil
+
+	case reflect.Interface:
+		return []string{name}, nil
+	}
+
+	segment, _, _ := strings.Cut(name, "_")
+	return nil, fmt.Errorf("unknown field %q", segment)
+}
+
+// Imposta il valore nell'albero al percorso indicato, creando i livelli intermedi.
+func setFlatPath(tree *ordered.OrderedMap, path []string, value string) error {
+	m := tree
+	for i, key := range path {
+		if i == len(path)-1 {
+			if _, isMap := m.Get(key).(*ordered.OrderedMap); isMap {
+				return errors.New("value conflicts with nested keys")
+			}
+			m.Set(key, value)
+			return nil
+		}
+
+		next := m.Get(key)
+		if next == nil {
+			next = ordered.NewOrderedMap()
+			m.Set(key, next)
+		}
+
+		sub, ok := next.(*ordered.OrderedMap)
+		if !ok {
+			return errors.New("nested keys conflict with value")
+		}
+		m = sub
+	}
+
+	return errors.New("value cannot replace the whole document")
+}
+
+// Scrive le variabili corrispondenti ai valori della mappa.
+//   - prefix: nome della variabile del livello superiore.
+//   - t: tipo da cui provengono i valori, per distinguere i campi dalle chiavi delle mappe; nil se sconosciuto.
+func writeDotenv(b *bytes.Buffer, m *ordered.OrderedMap, prefix string, t reflect.Type) error {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var fields map[string]reflect.StructField
+	if t != nil && t.Kind() == reflect.Struct {
+		fields = flatFields(t)
+	}
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		name := pair.Key
+		var eltType reflect.Type
+
+		switch {
+		case fields != nil:
+			name = DotenvKey(name)
+			if field, ok := fields[strings.ToLower(pair.Key)]; ok {
+				eltType = field.Type
+			}
+		case t != nil && t.Kind() == reflect.Map:
+			eltType = t.Elem()
+		}
+
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		err := writeDotenvValue(b, name, pair.Value, eltType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeDotenvValue(b *bytes.Buffer, name string, v interface{}, t reflect.Type) error {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch vv := v.(type) {
+	case *ordered.OrderedMap:
+		return writeDotenv(b, vv, name, t)
+
+	case []interface{}:
+		if len(vv) > 0 {
+			if _, isMap := vv[0].(*ordered.OrderedMap); isMap {
+				var eltType reflect.Type
+				if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+					eltType = t.Elem()
+				}
+
+				for i, elt := range vv {
+					err := writeDotenvValue(b, fmt.Sprintf("%s_%d", name, i), elt, eltType)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+
+	case string:
+		b.WriteString(name + "=" + DotenvString(vv) + "\n")
+		return nil
+	}
+
+	text, ok := flatText(v, false)
+	if !ok {
+		return fmt.Errorf("unsupported value for %s", name)
+	}
+
+	b.WriteString(name + "=" + DotenvValue(text) + "\n")
+
+	return nil
+}
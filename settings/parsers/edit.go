@@ -0,0 +1,240 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Modifica dei documenti mantenendone commenti, ordine e formattazione (vedi SetJsonc, SetYaml, SetToml):
+// nel testo originale viene sostituito il solo valore indicato dal percorso,
+// o inserita la chiave dopo l'ultima del livello superiore se assente.
+// Il percorso ha la forma delle chiavi delle *KeyLines, ad es. "main.paramInt", "users[1].name";
+// le chiavi esistenti sono individuate senza distinzione tra maiuscole e minuscole,
+// quelle nuove vengono scritte come indicate.
+// Un indice pari alla lunghezza dell'array aggiunge un elemento in coda.
+
+// Livello di un percorso.
+type pathSegment struct {
+	key   string
+	index int // Indice dell'elemento di array, -1 per le chiavi.
+}
+
+// Suddivide il percorso nei livelli.
+func splitKeyPath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty key path")
+	}
+
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		name, indexes, _ := strings.Cut(part, "[")
+		if name != "" {
+			segments = append(segments, pathSegment{key: name, index: -1})
+		} else if indexes == "" || len(segments) > 0 {
+			return nil, fmt.Errorf("invalid key path %q", path)
+		}
+		if indexes == "" {
+			continue
+		}
+
+		for _, index := range strings.SplitAfter("["+indexes, "]") {
+			if index == "" {
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(index, "["), "]"))
+			if err != nil || n < 0 || !strings.HasSuffix(index, "]") {
+				return nil, fmt.Errorf("invalid key path %q", path)
+			}
+			segments = append(segments, pathSegment{index: n})
+		}
+	}
+
+	return segments, nil
+}
+
+// Ritorna il percorso dei livelli indicati, per gli errori.
+func joinSegments(segments []pathSegment) string {
+	path := ""
+	for _, segment := range segments {
+		switch {
+		case segment.index >= 0:
+			path += fmt.Sprintf("[%d]", segment.index)
+		case path == "":
+			path = segment.key
+		default:
+			path += "." + segment.key
+		}
+	}
+	return path
+}
+
+// Ritorna il valore annidato nelle chiavi dei livelli indicati, per le chiavi da inserire;
+// i livelli di array sono ammessi solo come primo elemento.
+func nestedValue(segments []pathSegment, v interface{}) (interface{}, error) {
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i].index >= 0 {
+			if segments[i].index > 0 {
+				return nil, fmt.Errorf("index out of range at %s", joinSegments(segments[:i+1]))
+			}
+			v = []interface{}{v}
+			continue
+		}
+
+		m := ordered.NewOrderedMap()
+		m.Set(segments[i].key, v)
+		v = m
+	}
+
+	return v, nil
+}
+
+// Ritorna l'indentazione della riga contenente offset.
+func lineIndent(bb []byte, offset int) string {
+	start := bytes.LastIndexByte(bb[:offset], '\n') + 1
+	end := start
+	for end < len(bb) && (bb[end] == ' ' || bb[end] == '\t') {
+		end++
+	}
+	return string(bb[start:end])
+}
+
+// Ritorna l'unità di indentazione del documento, cioè quella della prima riga indentata;
+// def se non ve ne sono.
+func detectIndent(bb []byte, def string) string {
+	for _, row := range bytes.Split(bb, []byte("\n")) {
+		trimmed := bytes.TrimLeft(row, " \t")
+		if len(trimmed) < len(row) && len(bytes.TrimSpace(trimmed)) > 0 {
+			return string(row[:len(row)-len(trimmed)])
+		}
+	}
+	return def
+}
+
+// Ritorna la fine della riga contenente offset (posizione del newline o fine documento).
+func lineEnd(bb []byte, offset int) int {
+	if i := bytes.IndexByte(bb[offset:], '\n'); i >= 0 {
+		end := offset + i
+		if end > 0 && bb[end-1] == '\r' {
+			end--
+		}
+		return end
+	}
+	return len(bb)
+}
+
+// Sostituisce il testo compreso tra start ed end.
+func splice(bb []byte, start, end int, text string) []byte {
+	out := make([]byte, 0, len(bb)-(end-start)+len(text))
+	out = append(out, bb[:start]...)
+	out = append(out, text...)
+	return append(out, bb[end:]...)
+}
+
+// Codifica il valore in Json, senza escape Html.
+//   - indent: indentazione delle righe successive alla prima;
+//   - unit: unità di indentazione, stringa vuota per la forma compatta.
+func renderJson(v interface{}, indent, unit string) (string, error) {
+	var b bytes.Buffer
+
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if unit != "" {
+		e.SetIndent(indent, unit)
+	}
+
+	err := e.Encode(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// Codifica il valore in Json su una sola riga, con gli spazi dopo ':' e ','.
+func renderJsonInline(v interface{}) (string, error) {
+	text, err := renderJson(v, "", "\t")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, row := range strings.Split(text, "\n") {
+		row = strings.TrimSpace(row)
+		if i > 0 && !strings.HasSuffix(b.String(), "{") && !strings.HasSuffix(b.String(), "[") &&
+			!strings.HasPrefix(row, "}") && !strings.HasPrefix(row, "]") {
+			b.WriteByte(' ')
+		}
+		b.WriteString(row)
+	}
+
+	return b.String(), nil
+}
+
+// Imposta il valore al percorso indicato nell'albero decodificato (mappe e slice),
+// individuando le chiavi senza distinzione tra maiuscole e minuscole; ritorna l'albero aggiornato.
+func setTreeValue(tree interface{}, segments []pathSegment, v interface{}) (interface{}, error) {
+	if len(segments) == 0 {
+		return v, nil
+	}
+	segment := segments[0]
+
+	switch t := tree.(type) {
+	case map[string]interface{}:
+		if segment.index >= 0 {
+			break
+		}
+		key := segment.key
+		for k := range t {
+			if strings.EqualFold(k, segment.key) {
+				key = k
+				break
+			}
+		}
+		child, ok := t[key]
+		if !ok {
+			nested, err := nestedValue(segments[1:], v)
+			if err != nil {
+				return nil, err
+			}
+			t[key] = nested
+			return t, nil
+		}
+		child, err := setTreeValue(child, segments[1:], v)
+		if err != nil {
+			return nil, err
+		}
+		t[key] = child
+		return t, nil
+
+	case []interface{}:
+		if segment.index < 0 {
+			break
+		}
+		switch {
+		case segment.index < len(t):
+			child, err := setTreeValue(t[segment.index], segments[1:], v)
+			if err != nil {
+				return nil, err
+			}
+			t[segment.index] = child
+			return t, nil
+		case segment.index == len(t):
+			nested, err := nestedValue(segments[1:], v)
+			if err != nil {
+				return nil, err
+			}
+			return append(t, nested), nil
+		}
+		return nil, fmt.Errorf("index out of range at [%d]", segment.index)
+	}
+
+	if segment.index >= 0 {
+		return nil, fmt.Errorf("cannot set [%d]: not an array", segment.index)
+	}
+	return nil, fmt.Errorf("cannot set %s: not an object", segment.key)
+}
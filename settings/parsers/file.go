@@ -0,0 +1,147 @@
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Permessi predefiniti dei nuovi file: i file di configurazione possono contenere secret.
+const DefaultFileMode os.FileMode = 0600
+
+// Opzioni di scrittura dei file (vedi WriteFile).
+type WriteOptions struct {
+	Mode    os.FileMode // Permessi dei nuovi file, 0 per DefaultFileMode; i file esistenti mantengono i propri.
+	Backups int         // Numero di copie di backup del file esistente (file.bak, file.bak.1, ...), 0 per nessuna.
+}
+
+// Scrive il file in modo atomico: i dati vengono scritti in un file temporaneo nella stessa directory,
+// sincronizzato su disco e quindi rinominato sul file di destinazione,
+// che in caso di errore resta invariato.
+// Se il file esiste già ne vengono mantenuti permessi e, se consentito all'utente corrente, proprietario;
+// se è un link simbolico viene sostituito il file a cui punta.
+//   - opts: opzioni di scrittura, nil per le predefinite.
+func WriteFile(filename string, data []byte, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+
+	mode := opts.Mode
+	if mode == 0 {
+		mode = DefaultFileMode
+	}
+
+	info, err := os.Stat(filename)
+	switch {
+	case err == nil:
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", filename)
+		}
+		mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+
+	err = writeTemp(f, data, mode, info)
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if info != nil && opts.Backups > 0 {
+		err = rotateBackups(filename, opts.Backups, info.Mode().Perm())
+		if err != nil {
+			os.Remove(tmpName)
+			return fmt.Errorf("cannot backup %s: %w", filename, err)
+		}
+	}
+
+	err = os.Rename(tmpName, filename)
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return syncDir(filepath.Dir(filename))
+}
+
+// Scrive i dati nel file temporaneo impostandone permessi e proprietario (di existing, se non nil),
+// quindi lo sincronizza e lo chiude.
+func writeTemp(f *os.File, data []byte, mode os.FileMode, existing os.FileInfo) error {
+	_, err := f.Write(data)
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if err == nil && existing != nil {
+		err = chownLike(f, existing)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Ruota le copie di backup del file, mantenendone al più n:
+// file.bak è la più recente, seguita da file.bak.1, file.bak.2, ...
+func rotateBackups(filename string, n int, mode os.FileMode) error {
+	name := func(i int) string {
+		if i == 0 {
+			return filename + ".bak"
+		}
+		return fmt.Sprintf("%s.bak.%d", filename, i)
+	}
+
+	err := os.Remove(name(n - 1))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := n - 2; i >= 0; i-- {
+		err = os.Rename(name(i), name(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return copyFile(filename, name(0), mode)
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package parsers
+
+import "os"
+
+// Il proprietario dei file non è gestito.
+func chownLike(_ *os.File, _ os.FileInfo) error {
+	return nil
+}
+
+// La sincronizzazione delle directory non è supportata.
+func syncDir(_ string) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package parsers
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Imposta il proprietario del file come quello di info, se diverso.
+// Senza i privilegi necessari (ad es. utente non root che salva un file di root) ne viene mantenuto,
+// se possibile, il solo gruppo: il file resta di proprietà dell'utente corrente, senza errore.
+func chownLike(f *os.File, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	current, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if cur, ok := current.Sys().(*syscall.Stat_t); ok && cur.Uid == stat.Uid && cur.Gid == stat.Gid {
+		return nil
+	}
+
+	err = f.Chown(int(stat.Uid), int(stat.Gid))
+	if errors.Is(err, syscall.EPERM) {
+		_ = f.Chown(-1, int(stat.Gid))
+		return nil
+	}
+
+	return err
+}
+
+// Sincronizza la directory, affinché la rinomina sia persistente.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
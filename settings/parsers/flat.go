@@ -0,0 +1,525 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Formati testuali privi di tipi (Ini, Dotenv, ...): il documento viene letto in un albero
+// di mappe ordinate con valori stringa, che vengono convertiti nel tipo del campo destinatario
+// e quindi decodificati in modo stretto come per gli altri formati.
+//   - le chiavi dei campi delle strutture sono case insensitive;
+//   - slice e array sono indicati come elenco separato da virgole, oppure con sotto-chiavi numeriche
+//     (es. "users.0.name") per gli elementi di tipo struttura;
+//   - i valori possono essere racchiusi tra doppi apici (con le sequenze di escape Go) o tra apici singoli;
+//   - i booleani accettano anche yes/no e on/off;
+//   - un valore vuoto corrisponde a un elenco vuoto per slice e array, a null per gli altri tipi non stringa.
+
+// Errore di conversione di un valore nel tipo del campo destinatario.
+type ValueError struct {
+	Key   string       // Percorso della chiave (vedi *KeyLines).
+	Value string       // Valore letto.
+	Type  reflect.Type // Tipo del campo destinatario.
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("cannot use %s as %s for key %s", e.Value, e.Type, e.Key)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Decodifica l'albero nella struttura data.
+func decodeFlat(tree *ordered.OrderedMap, data interface{}) error {
+	t := reflect.TypeOf(data)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return errors.New("data must be a pointer")
+	}
+
+	v, err := flatValue(tree, t, "")
+	if err != nil {
+		return err
+	}
+
+	bb, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(bb))
+	d.DisallowUnknownFields()
+
+	return d.Decode(data)
+}
+
+// Converte il valore letto (stringa o albero) nel valore Json adatto al tipo t.
+// I numeri interi sono in base 10, come nei decoder Json, Yaml e Toml.
+//   - path: percorso della chiave, per gli errori.
+func flatValue(v interface{}, t reflect.Type, path string) (interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	s, isString := v.(string)
+	m, isMap := v.(*ordered.OrderedMap)
+
+	if isString {
+		s = strings.TrimSpace(s)
+
+		if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+			return unquoteValue(s), nil
+		}
+		if s == "" {
+			switch t.Kind() {
+			case reflect.String, reflect.Interface:
+				return "", nil
+			case reflect.Slice, reflect.Array:
+				return []interface{}{}, nil
+			}
+			return nil, nil
+		}
+	}
+
+	valueErr := func() error {
+		value := strconv.Quote(s)
+		if isMap {
+			value = "nested keys"
+		}
+		return &ValueError{Key: path, Value: value, Type: t}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if isString {
+			return unquoteValue(s), nil
+		}
+		return flatMap(m, t, path)
+
+	case reflect.Struct:
+		if isMap {
+			return flatStruct(m, t, path)
+		}
+
+	case reflect.Map:
+		if isMap {
+			return flatMap(m, t.Elem(), path)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if isString && t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return unquoteValue(s), nil // []byte in base64, come in Json
+		}
+
+		var items []interface{}
+		if isString {
+			for _, item := range splitList(s) {
+				items = append(items, item)
+			}
+		} else {
+			var err error
+			items, err = flatIndexed(m, path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		list := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			value, err := flatValue(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+
+	case reflect.String:
+		if isString {
+			return unquoteValue(s), nil
+		}
+
+	case reflect.Bool:
+		if isString {
+			switch strings.ToLower(unquoteValue(s)) {
+			case "1", "t", "true", "y", "yes", "on":
+				return true, nil
+			case "0", "f", "false", "n", "no", "off":
+				return false, nil
+			}
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isString {
+			if n, err := strconv.ParseInt(unquoteValue(s), 10, t.Bits()); err == nil {
+				return json.Number(strconv.FormatInt(n, 10)), nil
+			}
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if isString {
+			if n, err := strconv.ParseUint(unquoteValue(s), 10, t.Bits()); err == nil {
+				return json.Number(strconv.FormatUint(n, 10)), nil
+			}
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if isString {
+			if f, err := strconv.ParseFloat(unquoteValue(s), t.Bits()); err == nil {
+				return json.Number(strconv.FormatFloat(f, 'g', -1, t.Bits())), nil
+			}
+		}
+	}
+
+	return nil, valueErr()
+}
+
+// Converte le chiavi nei nomi dei campi della struttura (case insensitive),
+// segnalando quelle non previste.
+// Le chiavi ripetute con differenti maiuscole/minuscole vengono unite.
+func flatStruct(m *ordered.OrderedMap, t reflect.Type, path string) (*ordered.OrderedMap, error) {
+	fields := flatFields(t)
+
+	// Raggruppa le chiavi per campo.
+	merged := ordered.NewOrderedMap()
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		field, ok := fields[strings.ToLower(pair.Key)]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", pair.Key)
+		}
+
+		prev, prevIsMap := merged.Get(field.Name).(*ordered.OrderedMap)
+		value, isMap := pair.Value.(*ordered.OrderedMap)
+		if prevIsMap && isMap {
+			union := ordered.NewOrderedMap()
+			for _, src := range []*ordered.OrderedMap{prev, value} {
+				it := src.EntriesIter()
+				for {
+					p, ok := it()
+					if !ok {
+						break
+					}
+					union.Set(p.Key, p.Value)
+				}
+			}
+			merged.Set(field.Name, union)
+			continue
+		}
+
+		merged.Set(field.Name, pair.Value)
+	}
+
+	out := ordered.NewOrderedMap()
+	iter = merged.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		value, err := flatValue(pair.Value, fields[strings.ToLower(pair.Key)].Type, joinFlatPath(path, pair.Key))
+		if err != nil {
+			return nil, err
+		}
+		out.Set(pair.Key, value)
+	}
+
+	return out, nil
+}
+
+// Converte i valori della mappa nel tipo t.
+func flatMap(m *ordered.OrderedMap, t reflect.Type, path string) (*ordered.OrderedMap, error) {
+	out := ordered.NewOrderedMap()
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		value, err := flatValue(pair.Value, t, joinFlatPath(path, pair.Key))
+		if err != nil {
+			return nil, err
+		}
+		out.Set(pair.Key, value)
+	}
+
+	return out, nil
+}
+
+// Ritorna gli elementi di un elenco indicato con sotto-chiavi numeriche, in ordine di indice.
+func flatIndexed(m *ordered.OrderedMap, path string) ([]interface{}, error) {
+	type indexed struct {
+		index int
+		value interface{}
+	}
+	var items []indexed
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		index, err := strconv.Atoi(pair.Key)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid index %q for key %s", pair.Key, path)
+		}
+		items = append(items, indexed{index, pair.Value})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].index < items[j].index
+	})
+
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		values[i] = item.value
+	}
+
+	return values, nil
+}
+
+// Ritorna i campi della struttura per nome Json in minuscolo, secondo le regole del decoder Json
+// (tag `json`, campi delle strutture embedded prive di tag).
+// Il nome dei campi ritornati è quello atteso dal decoder.
+func flatFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for k, f := range flatFields(ft) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = f
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		field.Name = name
+		fields[strings.ToLower(name)] = field
+	}
+
+	return fields
+}
+
+// Percorso della chiave nella forma delle *KeyLines: le sotto-chiavi numeriche diventano indici.
+func joinFlatPath(path, key string) string {
+	if _, err := strconv.Atoi(key); err == nil && path != "" {
+		return path + "[" + key + "]"
+	}
+
+	return joinKeyPath(path, key)
+}
+
+// Suddivide un elenco separato da virgole, ignorando quelle all'interno dei valori tra apici.
+// Gli elementi non vengono privati degli apici.
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	var items []string
+	var quote rune
+	escaped := false
+	start := 0
+
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(items, strings.TrimSpace(s[start:]))
+}
+
+// Rimuove gli apici che racchiudono il valore, interpretando le sequenze di escape tra doppi apici.
+func unquoteValue(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+
+	switch {
+	case s[0] == '"' && s[len(s)-1] == '"':
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
+
+// Ritorna la rappresentazione testuale di una stringa nei formati privi di tipi (Ini, Dotenv, ...),
+// racchiusa tra doppi apici se altrimenti non verrebbe riletta invariata, anche come elemento di un elenco.
+func FlatString(s string) string {
+	quote := s != strings.TrimSpace(s) || strings.ContainsAny(s, `,"'`)
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			quote = true
+		}
+	}
+
+	if quote {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// Ritorna la rappresentazione testuale di un valore scalare o di un elenco di valori scalari;
+// ok è false se il valore contiene mappe o elenchi annidati.
+func flatText(v interface{}, inList bool) (text string, ok bool) {
+	switch vv := v.(type) {
+	case nil:
+		return "", true
+
+	case string:
+		if inList && vv == "" {
+			return `""`, true
+		}
+		return FlatString(vv), true
+
+	case []interface{}:
+		if inList {
+			return "", false
+		}
+
+		items := make([]string, len(vv))
+		for i, item := range vv {
+			items[i], ok = flatText(item, true)
+			if !ok {
+				return "", false
+			}
+		}
+		return strings.Join(items, ", "), true
+
+	case *ordered.OrderedMap:
+		return "", false
+	}
+
+	return fmt.Sprintf("%v", v), true
+}
+
+// Ritorna i dati come albero Json ordinato:
+// oggetti come *ordered.OrderedMap, array come []interface{}, numeri come json.Number.
+func orderedData(data interface{}) (interface{}, error) {
+	bb, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(bb), []byte("{")) {
+		m := ordered.NewOrderedMap()
+		err = json.Unmarshal(bb, m)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	// Altri valori: decodificati all'interno di un oggetto, per mantenere l'ordine degli oggetti annidati.
+	m := ordered.NewOrderedMap()
+	err = json.Unmarshal(append(append([]byte(`{"v":`), bb...), '}'), m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Get("v"), nil
+}
+
+// Converte i numeri Json nel tipo Go corrispondente, per la codifica dei formati diversi da Json.
+func scalarValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// Converte l'albero ordinato (vedi orderedData) in mappe e slice semplici, per gli encoder.
+func plainData(v interface{}) interface{} {
+	switch t := v.(type) {
+	case *ordered.OrderedMap:
+		m := make(map[string]interface{})
+		iter := t.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+			m[pair.Key] = plainData(pair.Value)
+		}
+		return m
+
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			items[i] = plainData(item)
+		}
+		return items
+	}
+
+	return scalarValue(v)
+}
+
+// Verifica se la mappa ordinata è vuota.
+func emptyMap(m *ordered.OrderedMap) bool {
+	_, ok := m.EntriesIter()()
+	return !ok
+}
@@ -0,0 +1,957 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Hcl (https://github.com/hashicorp/hcl): sottoinsieme della sintassi nativa di HCL2 limitato ai valori letterali.
+// Attributi "nome = valore" e blocchi "nome { ... }" corrispondono alle strutture annidate;
+// i blocchi ripetuti corrispondono agli elenchi di strutture, le etichette dei blocchi
+// (es. servers "web" { ... }) alle chiavi delle mappe.
+// Valori: stringhe tra doppi apici, numeri, booleani, null, tuple [...] e oggetti { ... }.
+// Non sono supportati, e vengono segnalati come errore: espressioni e operatori, variabili,
+// chiamate di funzione, espressioni for, heredoc ("<<EOT") e template ("${...}", "%{...}");
+// le sequenze "$${" e "%%{" sono i letterali "${" e "%{", ad es. per l'interpolazione della configurazione.
+// Commenti: '#', "//" e "/* */".
+
+func LoadHclFile(filename string, data interface{}) error {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return LoadHcl(bb, data)
+}
+
+func LoadHcl(bb []byte, data interface{}) error {
+	p := newHclParser(bb)
+
+	body, err := p.body("", false)
+	if err != nil {
+		return err
+	}
+
+	t := reflect.TypeOf(data)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return errors.New("data must be a pointer")
+	}
+
+	v, err := hclConvert(body, t)
+	if err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.DisallowUnknownFields()
+
+	err = d.Decode(data)
+
+	// Gli offset si riferiscono al Json intermedio: si riporta la chiave.
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &ValueError{Key: strings.ToLower(typeErr.Field), Value: typeErr.Value, Type: typeErr.Type}
+	}
+
+	return err
+}
+
+func SaveHclFile(filename string, data interface{}) error {
+	b, err := SaveHcl(data)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(filename, b, nil)
+}
+
+// Codifica in Hcl: le strutture annidate e gli elenchi di strutture sono scritti come blocchi,
+// le mappe come oggetti.
+func SaveHcl(data interface{}) ([]byte, error) {
+	v, err := orderedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(*ordered.OrderedMap)
+	if !ok {
+		return nil, errors.New("hcl document must be an object")
+	}
+
+	var b bytes.Buffer
+	writeHclBody(&b, m, reflect.TypeOf(data), "")
+
+	return b.Bytes(), nil
+}
+
+// Chiavi presenti in un documento Hcl (vedi JsoncKeyLines).
+// I blocchi non ripetuti sono riportati sia con che senza indice, es. "main.paramint" e "main[0].paramint",
+// non potendo stabilire senza il tipo destinatario se corrispondano a una struttura o a un elenco.
+func HclKeyLines(bb []byte) (map[string]int, error) {
+	p := newHclParser(bb)
+
+	_, err := p.body("", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.lines, nil
+}
+
+// Direttiva di inclusione di un documento Hcl, come attributo di primo livello.
+// Il documento viene ritornato con le righe della direttiva vuote, mantenendo le righe.
+func HclIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	p := newHclParser(bb)
+
+	body, err := p.body("", false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iter := body.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		if !strings.EqualFold(pair.Key, IncludeKey) {
+			continue
+		}
+		if _, isBlock := pair.Value.([]*hclBlock); isBlock {
+			return nil, nil, errors.New(IncludeKey + " must be a string or an array of strings")
+		}
+
+		includes, err = includePaths(pair.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Svuota le righe dell'attributo.
+		span := p.spans[pair.Key]
+		rest = append([]byte(nil), bb...)
+		for i := span[0]; i < span[1]; i++ {
+			if rest[i] != '\n' && rest[i] != '\r' {
+				rest[i] = ' '
+			}
+		}
+
+		return includes, rest, nil
+	}
+
+	return nil, bb, nil
+}
+
+// Ritorna la stringa Hcl tra doppi apici.
+// Le sequenze "${" e "%{" vengono raddoppiate, affinché non siano interpretate come espressioni.
+func HclString(s string) string {
+	var b strings.Builder
+
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// Ritorna la chiave Hcl: non quotata se è un identificatore valido, altrimenti tra doppi apici.
+func HclKey(key string) string {
+	if isHclIdentifier(key) {
+		return key
+	}
+
+	return HclString(key)
+}
+
+func isHclIdentifier(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r) && r != '-') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// Blocco Hcl.
+type hclBlock struct {
+	labels []string
+	body   *ordered.OrderedMap
+	offset int // Offset dell'inizio del blocco.
+}
+
+// Parser della sintassi nativa Hcl.
+type hclParser struct {
+	src   []byte
+	pos   int
+	line  int
+	lines map[string]int
+	spans map[string][2]int // Inizio e fine degli attributi di primo livello.
+}
+
+func newHclParser(bb []byte) *hclParser {
+	p := &hclParser{src: bb, line: 1, lines: make(map[string]int), spans: make(map[string][2]int)}
+
+	if bytes.HasPrefix(bb, []byte("\xef\xbb\xbf")) {
+		p.pos = 3
+	}
+
+	return p
+}
+
+func (p *hclParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Offset: int64(p.pos)}
+}
+
+// Legge il corpo di un blocco (inBlock) o del documento: attributi e blocchi.
+// Gli attributi sono ritornati come valori, i blocchi come []*hclBlock.
+func (p *hclParser) body(path string, inBlock bool) (*ordered.OrderedMap, error) {
+	body := ordered.NewOrderedMap()
+
+	for {
+		p.skipSpaces(true)
+
+		if p.pos >= len(p.src) {
+			if inBlock {
+				return nil, p.errorf("unexpected end of input, expected '}'")
+			}
+			break
+		}
+		if p.src[p.pos] == '}' {
+			if !inBlock {
+				return nil, p.errorf("unexpected '}'")
+			}
+			break
+		}
+
+		start := p.pos
+		line := p.line
+
+		name := p.identifier()
+		if name == "" {
+			return nil, p.errorf("expected attribute or block")
+		}
+
+		p.skipSpaces(false)
+
+		if p.pos < len(p.src) && (p.src[p.pos] == '=' || p.src[p.pos] == ':') && !bytes.HasPrefix(p.src[p.pos:], []byte("==")) {
+			// Attributo.
+			p.pos++
+
+			if body.Has(name) {
+				return nil, &SyntaxError{Msg: fmt.Sprintf("duplicate attribute %s", name), Offset: int64(start)}
+			}
+
+			key := joinKeyPath(path, name)
+			p.lines[key] = line
+
+			value, err := p.expr(key)
+			if err != nil {
+				return nil, err
+			}
+			body.Set(name, value)
+
+			if path == "" && !inBlock {
+				p.spans[name] = [2]int{start, p.pos}
+			}
+
+			err = p.endOfItem()
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Blocco, con eventuali etichette.
+		var labels []string
+		for p.pos < len(p.src) && p.src[p.pos] != '{' {
+			var label string
+			var err error
+
+			if p.src[p.pos] == '"' {
+				label, err = p.str()
+				if err != nil {
+					return nil, err
+				}
+			} else if label = p.identifier(); label == "" {
+				return nil, p.errorf("expected '=' or block")
+			}
+
+			labels = append(labels, label)
+			p.skipSpaces(false)
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unexpected end of input, expected '{'")
+		}
+		p.pos++
+
+		blocks, isBlock := body.Get(name).([]*hclBlock)
+		if body.Has(name) && !isBlock {
+			return nil, &SyntaxError{Msg: fmt.Sprintf("block %s conflicts with attribute", name), Offset: int64(start)}
+		}
+
+		// Le etichette sono chiavi di mappe, i blocchi senza etichetta elementi di elenchi.
+		key := joinKeyPath(path, name)
+		if len(labels) > 0 {
+			for _, label := range labels {
+				p.lines[key] = line
+				key = joinKeyPath(key, label)
+			}
+		} else {
+			key = fmt.Sprintf("%s[%d]", key, countUnlabeled(blocks))
+		}
+		p.lines[key] = line
+
+		blockBody, err := p.body(key, true)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // '}'
+
+		body.Set(name, append(blocks, &hclBlock{labels: labels, body: blockBody, offset: start}))
+
+		err = p.endOfItem()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Blocchi non ripetuti: anche senza indice.
+	iter := body.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		blocks, isBlock := pair.Value.([]*hclBlock)
+		if !isBlock || countUnlabeled(blocks) != 1 {
+			continue
+		}
+
+		prefix := joinKeyPath(path, pair.Key)
+		for k, l := range p.lines {
+			if strings.HasPrefix(k, prefix+"[0]") {
+				p.lines[prefix+strings.TrimPrefix(k, prefix+"[0]")] = l
+			}
+		}
+	}
+
+	return body, nil
+}
+
+func countUnlabeled(blocks []*hclBlock) int {
+	n := 0
+	for _, block := range blocks {
+		if len(block.labels) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Verifica la fine di un attributo o di un blocco: fine riga, fine documento o '}'.
+func (p *hclParser) endOfItem() error {
+	p.skipSpaces(false)
+
+	if p.pos >= len(p.src) || p.src[p.pos] == '\n' || p.src[p.pos] == '}' {
+		return nil
+	}
+	if p.src[p.pos] == '\r' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\n' {
+		return nil
+	}
+
+	return p.errorf("expected newline after item")
+}
+
+// Legge un valore letterale, segnalando le espressioni che lo proseguono (operatori, indici, attributi).
+func (p *hclParser) expr(path string) (interface{}, error) {
+	value, err := p.literal(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces(false)
+	if p.pos < len(p.src) && strings.IndexByte("+-*/%<>=!&|?.[", p.src[p.pos]) >= 0 {
+		return nil, p.errorf("unsupported expression: only literal values are allowed")
+	}
+
+	return value, nil
+}
+
+// Legge un valore letterale.
+func (p *hclParser) literal(path string) (interface{}, error) {
+	p.skipSpaces(false)
+
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of input, expected value")
+	}
+
+	ch := p.src[p.pos]
+	switch {
+	case ch == '"':
+		return p.str()
+
+	case ch == '<' && bytes.HasPrefix(p.src[p.pos:], []byte("<<")):
+		return nil, p.errorf("heredoc strings are not supported, use a quoted string with \\n escapes")
+
+	case ch == '[':
+		p.pos++
+
+		list := []interface{}{}
+		for i := 0; ; i++ {
+			p.skipSpaces(true)
+			if p.pos < len(p.src) && p.src[p.pos] == ']' {
+				p.pos++
+				return list, nil
+			}
+
+			key := fmt.Sprintf("%s[%d]", path, i)
+			p.lines[key] = p.line
+
+			value, err := p.expr(key)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+
+			p.skipSpaces(true)
+			if p.pos < len(p.src) && p.src[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.src) || p.src[p.pos] != ']' {
+				return nil, p.errorf("expected ',' or ']'")
+			}
+		}
+
+	case ch == '{':
+		p.pos++
+
+		m := ordered.NewOrderedMap()
+		for {
+			p.skipSpaces(true)
+			if p.pos < len(p.src) && p.src[p.pos] == '}' {
+				p.pos++
+				return m, nil
+			}
+
+			var name string
+			var err error
+			if p.pos < len(p.src) && p.src[p.pos] == '"' {
+				name, err = p.str()
+				if err != nil {
+					return nil, err
+				}
+			} else if name = p.identifier(); name == "" {
+				return nil, p.errorf("expected object key")
+			}
+
+			p.skipSpaces(false)
+			if p.pos >= len(p.src) || p.src[p.pos] != '=' && p.src[p.pos] != ':' {
+				return nil, p.errorf("expected '=' after object key")
+			}
+			p.pos++
+
+			key := joinKeyPath(path, name)
+			p.lines[key] = p.line
+
+			value, err := p.expr(key)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(name, value)
+
+			p.skipSpaces(false)
+			if p.pos < len(p.src) && p.src[p.pos] == ',' {
+				p.pos++
+			} else if p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' && p.src[p.pos] != '}' {
+				return nil, p.errorf("expected ',', newline or '}'")
+			}
+		}
+
+	case ch == '-' || ch >= '0' && ch <= '9':
+		return p.number()
+	}
+
+	start := p.pos
+	ident := p.identifier()
+	switch ident {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "":
+		return nil, p.errorf("invalid character %q, expected value", ch)
+	case "for":
+		p.pos = start
+		return nil, p.errorf("for expressions are not supported: only literal values are allowed")
+	}
+
+	p.skipSpaces(false)
+	if p.pos < len(p.src) && p.src[p.pos] == '(' {
+		p.pos = start
+		return nil, p.errorf("function calls are not supported: only literal values are allowed")
+	}
+
+	p.pos = start
+	return nil, p.errorf("unsupported expression %s: only literal values are allowed", ident)
+}
+
+// Legge una stringa tra doppi apici.
+func (p *hclParser) str() (string, error) {
+	var b strings.Builder
+
+	start := p.pos
+	p.pos++ // '"'
+
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+
+		switch {
+		case ch == '"':
+			p.pos++
+			return b.String(), nil
+
+		case ch == '\n':
+			return "", p.errorf("unterminated string")
+
+		case (ch == '$' || ch == '%') && p.pos+2 < len(p.src) && p.src[p.pos+1] == ch && p.src[p.pos+2] == '{':
+			// Sequenza "$${" o "%%{" letterale.
+			b.WriteByte(ch)
+			p.pos++
+
+		case (ch == '$' || ch == '%') && p.pos+1 < len(p.src) && p.src[p.pos+1] == '{':
+			return "", p.errorf("templates are not supported, use %c%c{ for a literal %c{", ch, ch, ch)
+
+		case ch == '\\':
+			if p.pos+1 >= len(p.src) {
+				return "", p.errorf("unterminated string")
+			}
+
+			esc := p.src[p.pos+1]
+			p.pos += 2
+			switch esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(esc)
+			case 'u', 'U':
+				n := 4
+				if esc == 'U' {
+					n = 8
+				}
+				if p.pos+n > len(p.src) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", p.errorf("invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += n
+			default:
+				p.pos -= 2
+				return "", p.errorf("invalid escape sequence \\%c", esc)
+			}
+			continue
+
+		default:
+			b.WriteByte(ch)
+		}
+
+		p.pos++
+	}
+
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// Legge un numero.
+func (p *hclParser) number() (json.Number, error) {
+	start := p.pos
+
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+			n++
+		}
+		return n
+	}
+
+	if digits() == 0 {
+		p.pos = start
+		return "", p.errorf("invalid number")
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		if digits() == 0 {
+			return "", p.errorf("invalid number")
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return "", p.errorf("invalid number")
+		}
+	}
+
+	n := json.Number(p.src[start:p.pos])
+
+	// Numero in notazione Json: senza zeri iniziali.
+	if _, err := strconv.ParseFloat(string(n), 64); err != nil {
+		p.pos = start
+		return "", p.errorf("invalid number %s", n)
+	}
+	sign, abs := "", string(n)
+	if strings.HasPrefix(abs, "-") {
+		sign, abs = "-", abs[1:]
+	}
+	for len(abs) > 1 && abs[0] == '0' && abs[1] >= '0' && abs[1] <= '9' {
+		abs = abs[1:]
+	}
+	n = json.Number(sign + abs)
+
+	return n, nil
+}
+
+// Legge un identificatore, stringa vuota se assente.
+func (p *hclParser) identifier() string {
+	start := p.pos
+
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRune(p.src[p.pos:])
+		if !unicode.IsLetter(r) && r != '_' && (p.pos == start || !unicode.IsDigit(r) && r != '-') {
+			break
+		}
+		p.pos += size
+	}
+
+	return string(p.src[start:p.pos])
+}
+
+// Salta spazi e commenti; i ritorni a capo solo se newlines è true.
+func (p *hclParser) skipSpaces(newlines bool) {
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+
+		switch {
+		case ch == '\n':
+			if !newlines {
+				return
+			}
+			p.line++
+			p.pos++
+
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			p.pos++
+
+		case ch == '#' || ch == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+
+		case ch == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '*':
+			end := bytes.Index(p.src[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				p.pos = len(p.src)
+				return
+			}
+			p.line += bytes.Count(p.src[p.pos:p.pos+2+end], []byte("\n"))
+			p.pos += end + 4
+
+		default:
+			return
+		}
+	}
+}
+
+// Converte i blocchi del corpo in base al tipo destinatario t:
+// elenchi di oggetti per slice e array, oggetto per gli altri tipi; le etichette in mappe annidate.
+func hclConvert(body *ordered.OrderedMap, t reflect.Type) (*ordered.OrderedMap, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var fields map[string]reflect.StructField
+	if t != nil && t.Kind() == reflect.Struct {
+		fields = flatFields(t)
+	}
+
+	out := ordered.NewOrderedMap()
+
+	iter := body.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		blocks, isBlock := pair.Value.([]*hclBlock)
+		if !isBlock {
+			out.Set(pair.Key, pair.Value)
+			continue
+		}
+
+		// Tipo del campo corrispondente al blocco.
+		var ft reflect.Type
+		switch {
+		case fields != nil:
+			field, ok := fields[strings.ToLower(pair.Key)]
+			if !ok {
+				return nil, fmt.Errorf("unknown field %q", pair.Key)
+			}
+			ft = field.Type
+		case t != nil && t.Kind() == reflect.Map:
+			ft = t.Elem()
+		}
+
+		value, err := hclBlocks(blocks, ft, pair.Key)
+		if err != nil {
+			return nil, err
+		}
+		out.Set(pair.Key, value)
+	}
+
+	return out, nil
+}
+
+// Converte i blocchi con lo stesso nome nel valore del campo di tipo t (nil se sconosciuto).
+func hclBlocks(blocks []*hclBlock, t reflect.Type, name string) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var list []interface{}
+	labeled := ordered.NewOrderedMap()
+
+	for _, block := range blocks {
+		if len(block.labels) == 0 {
+			if t != nil && t.Kind() != reflect.Slice && t.Kind() != reflect.Array && len(list) > 0 {
+				return nil, &SyntaxError{Msg: fmt.Sprintf("duplicate block %s", name), Offset: int64(block.offset)}
+			}
+
+			eltType := t
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				eltType = t.Elem()
+			}
+
+			body, err := hclConvert(block.body, eltType)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, body)
+			continue
+		}
+
+		// Etichette: mappe annidate.
+		m := labeled
+		eltType := t
+		for i, label := range block.labels {
+			for eltType != nil && eltType.Kind() == reflect.Pointer {
+				eltType = eltType.Elem()
+			}
+			if eltType != nil && eltType.Kind() == reflect.Map {
+				eltType = eltType.Elem()
+			} else if eltType != nil && eltType.Kind() != reflect.Interface {
+				return nil, &SyntaxError{Msg: fmt.Sprintf("unexpected label %q for block %s", label, name), Offset: int64(block.offset)}
+			}
+
+			if i == len(block.labels)-1 {
+				if m.Has(label) {
+					return nil, &SyntaxError{Msg: fmt.Sprintf("duplicate block %s %q", name, label), Offset: int64(block.offset)}
+				}
+
+				body, err := hclConvert(block.body, eltType)
+				if err != nil {
+					return nil, err
+				}
+				m.Set(label, body)
+				break
+			}
+
+			sub, ok := m.Get(label).(*ordered.OrderedMap)
+			if !ok {
+				sub = ordered.NewOrderedMap()
+				m.Set(label, sub)
+			}
+			m = sub
+		}
+	}
+
+	switch {
+	case len(list) > 0 && !emptyMap(labeled):
+		return nil, &SyntaxError{Msg: fmt.Sprintf("block %s mixes labeled and unlabeled blocks", name), Offset: int64(blocks[0].offset)}
+	case !emptyMap(labeled):
+		return labeled, nil
+	case t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) || len(list) > 1:
+		return list, nil
+	}
+
+	return list[0], nil
+}
+
+// Scrive gli attributi del corpo, quindi i blocchi.
+//   - t: tipo da cui provengono i valori; le strutture annidate e gli elenchi di strutture
+//     vengono scritti come blocchi, nil se sconosciuto.
+func writeHclBody(b *bytes.Buffer, m *ordered.OrderedMap, t reflect.Type, indent string) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var fields map[string]reflect.StructField
+	if t != nil && t.Kind() == reflect.Struct {
+		fields = flatFields(t)
+	}
+
+	type block struct {
+		name string
+		body *ordered.OrderedMap
+		t    reflect.Type
+	}
+	var blocks []block
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		if field, ok := fields[strings.ToLower(pair.Key)]; ok {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			switch vv := pair.Value.(type) {
+			case *ordered.OrderedMap:
+				if ft.Kind() == reflect.Struct {
+					blocks = append(blocks, block{pair.Key, vv, ft})
+					continue
+				}
+
+			case []interface{}:
+				if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+					eltType := ft.Elem()
+					for eltType.Kind() == reflect.Pointer {
+						eltType = eltType.Elem()
+					}
+
+					if eltType.Kind() == reflect.Struct && len(vv) > 0 {
+						for _, elt := range vv {
+							if body, ok := elt.(*ordered.OrderedMap); ok {
+								blocks = append(blocks, block{pair.Key, body, eltType})
+							}
+						}
+						continue
+					}
+				}
+			}
+		}
+
+		b.WriteString(indent + HclKey(pair.Key) + " = ")
+		writeHclValue(b, pair.Value, indent)
+		b.WriteString("\n")
+	}
+
+	for i, block := range blocks {
+		if i > 0 || b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("{\n")) {
+			b.WriteString("\n")
+		}
+
+		b.WriteString(indent + HclKey(block.name) + " {\n")
+		writeHclBody(b, block.body, block.t, indent+"  ")
+		b.WriteString(indent + "}\n")
+	}
+}
+
+// Scrive un valore letterale Hcl.
+func writeHclValue(b *bytes.Buffer, v interface{}, indent string) {
+	switch vv := v.(type) {
+	case *ordered.OrderedMap:
+		if emptyMap(vv) {
+			b.WriteString("{}")
+			return
+		}
+
+		b.WriteString("{\n")
+		iter := vv.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+
+			b.WriteString(indent + "  " + HclKey(pair.Key) + " = ")
+			writeHclValue(b, pair.Value, indent+"  ")
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+
+	case []interface{}:
+		b.WriteString("[")
+		for i, elt := range vv {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeHclValue(b, elt, indent)
+		}
+		b.WriteString("]")
+
+	case string:
+		b.WriteString(HclString(vv))
+
+	case nil:
+		b.WriteString("null")
+
+	default:
+		fmt.Fprintf(b, "%v", vv)
+	}
+}
@@ -0,0 +1,159 @@
+package parsers
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gitlab.com/c0b/go-ordered-json"
+	"gopkg.in/yaml.v3"
+)
+
+// Nome della chiave di primo livello per l'inclusione di altri file (case insensitive).
+const IncludeKey = "include"
+
+// Le funzioni *Includes estraggono la direttiva di inclusione di primo livello dal documento,
+// ritornando i percorsi inclusi e il documento privato della direttiva.
+// Se la direttiva è assente il documento viene ritornato invariato.
+// Il valore della direttiva può essere una stringa o un array di stringhe.
+
+// Direttiva di inclusione di un documento Json o Jsonc.
+func JsoncIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	lines, err := JsoncKeyLines(bb)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := lines[IncludeKey]; !ok {
+		return nil, bb, nil
+	}
+
+	m := ordered.NewOrderedMap()
+
+	err = json.Unmarshal(translate(bb), m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := ordered.NewOrderedMap()
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		if strings.EqualFold(pair.Key, IncludeKey) {
+			includes, err = includePaths(pair.Value)
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		out.Set(pair.Key, pair.Value)
+	}
+
+	rest, err = json.Marshal(out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return includes, rest, nil
+}
+
+// Direttiva di inclusione di un documento Yaml.
+func YamlIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	var doc yaml.Node
+
+	err = yaml.Unmarshal(bb, &doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, bb, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if !strings.EqualFold(root.Content[i].Value, IncludeKey) {
+			continue
+		}
+
+		var value interface{}
+		err = root.Content[i+1].Decode(&value)
+		if err != nil {
+			return nil, nil, err
+		}
+		includes, err = includePaths(value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+
+		rest, err = yaml.Marshal(&doc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return includes, rest, nil
+	}
+
+	return nil, bb, nil
+}
+
+// Direttiva di inclusione di un documento Toml.
+func TomlIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	var m map[string]interface{}
+
+	err = toml.Unmarshal(bb, &m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for k, v := range m {
+		if !strings.EqualFold(k, IncludeKey) {
+			continue
+		}
+
+		includes, err = includePaths(v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		delete(m, k)
+
+		rest, err = toml.Marshal(m)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return includes, rest, nil
+	}
+
+	return nil, bb, nil
+}
+
+// Converte il valore della direttiva di inclusione in un elenco di percorsi.
+func includePaths(v interface{}) ([]string, error) {
+	errInvalid := errors.New(IncludeKey + " must be a string or an array of strings")
+
+	switch vv := v.(type) {
+	case string:
+		return []string{vv}, nil
+
+	case []interface{}:
+		paths := make([]string, 0, len(vv))
+		for _, item := range vv {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errInvalid
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	}
+
+	return nil, errInvalid
+}
@@ -0,0 +1,282 @@
+package parsers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Ini: coppie "chiave = valore", raggruppate in sezioni "[section]" e "[section.sub]"
+// corrispondenti alle strutture annidate; le chiavi precedenti la prima sezione sono di primo livello.
+// Le righe che iniziano per ';' o '#' sono commenti; non sono previsti commenti in coda ai valori.
+// Per la conversione dei valori vedi flat.go; gli elementi di tipo struttura degli elenchi
+// sono sezioni con indice numerico, es. "[users.0]".
+
+func LoadIniFile(filename string, data interface{}) error {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return LoadIni(bb, data)
+}
+
+func LoadIni(bb []byte, data interface{}) error {
+	tree, _, err := parseIni(bb)
+	if err != nil {
+		return err
+	}
+
+	return decodeFlat(tree, data)
+}
+
+func SaveIniFile(filename string, data interface{}) error {
+	b, err := SaveIni(data)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(filename, b, nil)
+}
+
+// Codifica in Ini: i valori scalari di primo livello precedono le sezioni.
+func SaveIni(data interface{}) ([]byte, error) {
+	v, err := orderedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(*ordered.OrderedMap)
+	if !ok {
+		return nil, errors.New("ini document must be an object")
+	}
+
+	var b bytes.Buffer
+	err = writeIniSection(&b, m, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Chiavi presenti in un documento Ini (vedi JsoncKeyLines).
+// La riga delle sezioni è quella della prima intestazione.
+func IniKeyLines(bb []byte) (map[string]int, error) {
+	_, lines, err := parseIni(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// Direttiva di inclusione di un documento Ini, come chiave precedente la prima sezione;
+// il valore è un elenco di percorsi separati da virgole.
+// Il documento viene ritornato con la riga della direttiva vuota, mantenendo le righe.
+func IniIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	tree, lines, err := parseIni(bb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	found := false
+	iter := tree.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		s, isString := pair.Value.(string)
+		if !strings.EqualFold(pair.Key, IncludeKey) || !isString {
+			continue
+		}
+
+		for _, item := range splitList(s) {
+			includes = append(includes, unquoteValue(item))
+		}
+		found = true
+	}
+	if !found {
+		return nil, bb, nil
+	}
+
+	line := lines[IncludeKey]
+	rows := bytes.SplitAfter(bb, []byte("\n"))
+	rest = make([]byte, 0, len(bb))
+	for i, row := range rows {
+		if i == line-1 {
+			row = row[len(bytes.TrimRight(row, "\r\n")):]
+		}
+		rest = append(rest, row...)
+	}
+
+	return includes, rest, nil
+}
+
+// Legge il documento Ini nell'albero dei valori, ritornando anche le righe delle chiavi.
+func parseIni(bb []byte) (*ordered.OrderedMap, map[string]int, error) {
+	root := ordered.NewOrderedMap()
+	lines := make(map[string]int)
+
+	section := root
+	path := ""
+
+	doc := string(bb)
+	offset := 0
+	if strings.HasPrefix(doc, "\ufeff") {
+		offset = len("\ufeff")
+		doc = doc[offset:]
+	}
+
+	for i, row := range strings.SplitAfter(doc, "\n") {
+		start := offset
+		offset += len(row)
+
+		text := strings.TrimSpace(row)
+		start += strings.Index(row, text)
+
+		errorf := func(format string, args ...interface{}) error {
+			return &SyntaxError{Msg: fmt.Sprintf(format, args...), Offset: int64(start)}
+		}
+
+		switch {
+		case text == "" || text[0] == ';' || text[0] == '#':
+			continue
+
+		case text[0] == '[':
+			if text[len(text)-1] != ']' {
+				return nil, nil, errorf("missing ']' in section header")
+			}
+
+			section = root
+			path = ""
+			for _, name := range strings.Split(text[1:len(text)-1], ".") {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					return nil, nil, errorf("invalid section name %s", text)
+				}
+
+				value := section.Get(name)
+				if value == nil {
+					value = ordered.NewOrderedMap()
+					section.Set(name, value)
+				}
+
+				sub, ok := value.(*ordered.OrderedMap)
+				if !ok {
+					return nil, nil, errorf("section %s conflicts with key %s", text, name)
+				}
+
+				section = sub
+				path = joinFlatPath(path, name)
+				if _, ok = lines[path]; !ok {
+					lines[path] = i + 1
+				}
+			}
+
+		default:
+			key, value, ok := strings.Cut(text, "=")
+			if !ok {
+				return nil, nil, errorf("expected key = value")
+			}
+
+			key = strings.TrimSpace(key)
+			if key == "" {
+				return nil, nil, errorf("missing key")
+			}
+			if section.Has(key) {
+				return nil, nil, errorf("duplicate key %s", key)
+			}
+
+			section.Set(key, strings.TrimSpace(value))
+			lines[joinFlatPath(path, key)] = i + 1
+		}
+	}
+
+	return root, lines, nil
+}
+
+// Scrive i valori scalari della sezione, quindi le sezioni annidate.
+func writeIniSection(b *bytes.Buffer, m *ordered.OrderedMap, path string) error {
+	var values, sections []*ordered.KVPair
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		switch vv := pair.Value.(type) {
+		case *ordered.OrderedMap:
+			sections = append(sections, pair)
+			continue
+		case []interface{}:
+			if len(vv) > 0 {
+				if _, isMap := vv[0].(*ordered.OrderedMap); isMap {
+					sections = append(sections, pair)
+					continue
+				}
+			}
+		}
+		values = append(values, pair)
+	}
+
+	if path != "" && (len(values) > 0 || len(sections) == 0) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[" + path + "]\n")
+	}
+
+	for _, pair := range values {
+		text, ok := flatText(pair.Value, false)
+		if !ok {
+			return fmt.Errorf("unsupported value for key %s", joinIniSection(path, pair.Key))
+		}
+
+		b.WriteString(pair.Key)
+		if text == "" {
+			b.WriteString(" =\n")
+		} else {
+			b.WriteString(" = " + text + "\n")
+		}
+	}
+
+	for _, pair := range sections {
+		sub := joinIniSection(path, pair.Key)
+
+		switch vv := pair.Value.(type) {
+		case *ordered.OrderedMap:
+			if err := writeIniSection(b, vv, sub); err != nil {
+				return err
+			}
+
+		case []interface{}:
+			for i, elt := range vv {
+				eltMap, ok := elt.(*ordered.OrderedMap)
+				if !ok {
+					return fmt.Errorf("unsupported value for key %s", sub)
+				}
+				if err := writeIniSection(b, eltMap, fmt.Sprintf("%s.%d", sub, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func joinIniSection(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
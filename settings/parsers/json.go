@@ -30,19 +30,12 @@ func LoadJson(bb []byte, data interface{}) error {
 }
 
 func SaveJsonFile(filename string, data interface{}) error {
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
 	b, err := SaveJson(data)
 	if err != nil {
 		return err
 	}
-	err = os.WriteFile(filename, b, 0666)
 
-	return err
+	return WriteFile(filename, b, nil)
 }
 
 func SaveJson(data interface{}) ([]byte, error) {
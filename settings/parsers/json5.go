@@ -0,0 +1,580 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Json5 (https://json5.org): Json con commenti, virgole finali, chiavi non quotate,
+// stringhe tra apici singoli, numeri esadecimali o con il punto iniziale o finale.
+// Il documento viene convertito in Json mantenendo le righe, riportando le posizioni
+// degli errori del decoder al documento originale.
+// Infinity e NaN non sono supportati, non essendo rappresentabili in Json.
+
+func LoadJson5File(filename string, data interface{}) error {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return LoadJson5(bb, data)
+}
+
+func LoadJson5(bb []byte, data interface{}) error {
+	// Converte da Json5 a Json.
+	bb, offsets, err := json5ToJson(bb)
+	if err != nil {
+		return err
+	}
+
+	r := bytes.NewReader(bb)
+
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+
+	err = d.Decode(data)
+	if err != nil {
+		return json5Error(err, offsets)
+	}
+
+	return nil
+}
+
+func SaveJson5File(filename string, data interface{}) error {
+	b, err := SaveJson5(data)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(filename, b, nil)
+}
+
+// Codifica in Json5 con chiavi non quotate (ove possibile), stringhe tra apici singoli e virgole finali.
+func SaveJson5(data interface{}) ([]byte, error) {
+	v, err := orderedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	writeJson5(&b, v, "")
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
+
+// Chiavi presenti in un documento Json5 (vedi JsoncKeyLines).
+func Json5KeyLines(bb []byte) (map[string]int, error) {
+	bb, _, err := json5ToJson(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	return JsoncKeyLines(bb)
+}
+
+// Direttiva di inclusione di un documento Json5 (vedi JsoncIncludes).
+// Se presente, il documento privato della direttiva viene ritornato in Json.
+func Json5Includes(bb []byte) (includes []string, rest []byte, err error) {
+	j, _, err := json5ToJson(bb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	includes, rest, err = JsoncIncludes(j)
+	if err != nil {
+		return nil, nil, err
+	}
+	if bytes.Equal(rest, j) {
+		return nil, bb, nil
+	}
+
+	return includes, rest, nil
+}
+
+// Riporta gli offset degli errori del decoder Json al documento Json5 originale.
+func json5Error(err error, offsets []int) error {
+	original := func(offset int64) int64 {
+		// Offset = byte letti al momento dell'errore.
+		if offset <= 0 || len(offsets) == 0 {
+			return offset
+		}
+		if offset > int64(len(offsets)) {
+			offset = int64(len(offsets))
+		}
+		return int64(offsets[offset-1]) + 1
+	}
+
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		e.Offset = original(e.Offset)
+	case *json.UnmarshalTypeError:
+		e.Offset = original(e.Offset)
+	}
+
+	return err
+}
+
+// Convertitore da Json5 a Json.
+type json5Converter struct {
+	src     []byte
+	pos     int
+	out     []byte
+	offsets []int // Per ciascun byte di out, l'offset in src da cui proviene.
+	comma   int   // Posizione in out dell'ultima virgola non ancora seguita da un valore.
+}
+
+// Converte il documento Json5 in Json, mantenendo le righe;
+// ritorna anche, per ciascun byte convertito, il suo offset nel documento originale.
+func json5ToJson(src []byte) ([]byte, []int, error) {
+	c := &json5Converter{
+		src:     src,
+		out:     make([]byte, 0, len(src)+len(src)/4),
+		offsets: make([]int, 0, len(src)+len(src)/4),
+		comma:   -1,
+	}
+
+	for c.pos < len(c.src) {
+		err := c.token()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return c.out, c.offsets, nil
+}
+
+func (c *json5Converter) emit(s string, offset int) {
+	for i := 0; i < len(s); i++ {
+		c.out = append(c.out, s[i])
+		c.offsets = append(c.offsets, offset)
+	}
+}
+
+func (c *json5Converter) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Offset: int64(c.pos)}
+}
+
+func (c *json5Converter) token() error {
+	ch := c.src[c.pos]
+	r, size := utf8.DecodeRune(c.src[c.pos:])
+
+	switch {
+	case ch == '\n':
+		c.emit("\n", c.pos)
+		c.pos++
+
+	case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\v' || ch == '\f':
+		c.emit(" ", c.pos)
+		c.pos++
+
+	case r == '\ufeff' || r == '\u2028' || r == '\u2029' || unicode.Is(unicode.Zs, r):
+		c.emit(strings.Repeat(" ", size), c.pos)
+		c.pos += size
+
+	case ch == '/' && c.pos+1 < len(c.src) && c.src[c.pos+1] == '/':
+		for c.pos < len(c.src) && c.src[c.pos] != '\n' {
+			c.emit(" ", c.pos)
+			c.pos++
+		}
+
+	case ch == '/' && c.pos+1 < len(c.src) && c.src[c.pos+1] == '*':
+		end := bytes.Index(c.src[c.pos+2:], []byte("*/"))
+		if end < 0 {
+			return c.errorf("unterminated comment")
+		}
+		for end = c.pos + end + 4; c.pos < end; c.pos++ {
+			if c.src[c.pos] == '\n' {
+				c.emit("\n", c.pos)
+			} else {
+				c.emit(" ", c.pos)
+			}
+		}
+
+	case ch == ',':
+		c.comma = len(c.out)
+		c.emit(",", c.pos)
+		c.pos++
+
+	case ch == '}' || ch == ']':
+		// Virgola finale.
+		if c.comma >= 0 {
+			c.out[c.comma] = ' '
+		}
+		c.comma = -1
+		c.emit(string(ch), c.pos)
+		c.pos++
+
+	case ch == '"' || ch == '\'':
+		c.comma = -1
+		return c.str()
+
+	case ch >= '0' && ch <= '9' || ch == '+' || ch == '-' || ch == '.':
+		c.comma = -1
+		return c.number()
+
+	case ch == '_' || ch == '$' || unicode.IsLetter(r):
+		c.comma = -1
+		return c.identifier()
+
+	default:
+		// Il decoder Json segnalerà l'eventuale carattere non valido.
+		c.comma = -1
+		c.emit(string(c.src[c.pos:c.pos+size]), c.pos)
+		c.pos += size
+	}
+
+	return nil
+}
+
+// Converte una stringa tra apici singoli o doppi in una stringa Json.
+func (c *json5Converter) str() error {
+	start := c.pos
+	quote := c.src[c.pos]
+	newlines := 0 // Newline delle continuazioni di riga, riportati dopo la stringa.
+
+	c.emit(`"`, c.pos)
+	c.pos++
+
+	for c.pos < len(c.src) {
+		ch := c.src[c.pos]
+
+		switch {
+		case ch == quote:
+			c.emit(`"`, c.pos)
+			c.emit(strings.Repeat("\n", newlines), c.pos)
+			c.pos++
+			return nil
+
+		case ch == '\n' || ch == '\r':
+			return c.errorf("unterminated string")
+
+		case ch == '"':
+			c.emit(`\"`, c.pos)
+			c.pos++
+
+		case ch < 0x20:
+			c.emit(fmt.Sprintf(`\u%04x`, ch), c.pos)
+			c.pos++
+
+		case ch == '\\':
+			if c.pos+1 >= len(c.src) {
+				return c.errorf("unterminated string")
+			}
+
+			esc := c.src[c.pos+1]
+			switch esc {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				c.emit(`\`+string(esc), c.pos)
+				c.pos += 2
+
+			case '\'':
+				c.emit("'", c.pos)
+				c.pos += 2
+
+			case 'v':
+				c.emit(`\u000b`, c.pos)
+				c.pos += 2
+
+			case '0':
+				c.emit(`\u0000`, c.pos)
+				c.pos += 2
+
+			case 'u':
+				if c.pos+6 > len(c.src) {
+					return c.errorf("invalid unicode escape")
+				}
+				c.emit(string(c.src[c.pos:c.pos+6]), c.pos)
+				c.pos += 6
+
+			case 'x':
+				if c.pos+4 > len(c.src) {
+					return c.errorf("invalid hexadecimal escape")
+				}
+				c.emit(`\u00`+string(c.src[c.pos+2:c.pos+4]), c.pos)
+				c.pos += 4
+
+			case '\n':
+				// Continuazione di riga.
+				newlines++
+				c.pos += 2
+
+			case '\r':
+				newlines++
+				c.pos += 2
+				if c.pos < len(c.src) && c.src[c.pos] == '\n' {
+					c.pos++
+				}
+
+			default:
+				// Qualsiasi altro carattere rappresenta se stesso.
+				_, size := utf8.DecodeRune(c.src[c.pos+1:])
+				c.emit(string(c.src[c.pos+1:c.pos+1+size]), c.pos)
+				c.pos += 1 + size
+			}
+
+		default:
+			c.emit(string(ch), c.pos)
+			c.pos++
+		}
+	}
+
+	c.pos = start
+	return c.errorf("unterminated string")
+}
+
+// Converte un numero, eventualmente con segno '+', esadecimale o con il punto iniziale o finale.
+func (c *json5Converter) number() error {
+	start := c.pos
+
+	switch c.src[c.pos] {
+	case '-':
+		c.emit("-", c.pos)
+		c.pos++
+	case '+':
+		c.pos++
+	}
+
+	if c.pos < len(c.src) && (c.src[c.pos] == 'I' || c.src[c.pos] == 'N') {
+		c.pos = start
+		return c.errorf("Infinity and NaN are not supported")
+	}
+
+	// Esadecimale.
+	if c.pos+1 < len(c.src) && c.src[c.pos] == '0' && (c.src[c.pos+1] == 'x' || c.src[c.pos+1] == 'X') {
+		end := c.pos + 2
+		for end < len(c.src) && strings.IndexByte("0123456789abcdefABCDEF", c.src[end]) >= 0 {
+			end++
+		}
+
+		n, err := strconv.ParseUint(string(c.src[c.pos+2:end]), 16, 64)
+		if err != nil {
+			return c.errorf("invalid hexadecimal number")
+		}
+
+		c.emit(strconv.FormatUint(n, 10), c.pos)
+		c.pos = end
+		return nil
+	}
+
+	digits := func() {
+		for c.pos < len(c.src) && c.src[c.pos] >= '0' && c.src[c.pos] <= '9' {
+			c.emit(string(c.src[c.pos]), c.pos)
+			c.pos++
+		}
+	}
+
+	integer := c.pos
+	digits()
+	if c.pos == integer {
+		c.emit("0", c.pos)
+	}
+
+	if c.pos < len(c.src) && c.src[c.pos] == '.' {
+		c.emit(".", c.pos)
+		c.pos++
+
+		fraction := c.pos
+		digits()
+		if c.pos == fraction {
+			c.emit("0", c.pos)
+		}
+	}
+
+	if c.pos < len(c.src) && (c.src[c.pos] == 'e' || c.src[c.pos] == 'E') {
+		c.emit("e", c.pos)
+		c.pos++
+
+		if c.pos < len(c.src) && (c.src[c.pos] == '+' || c.src[c.pos] == '-') {
+			c.emit(string(c.src[c.pos]), c.pos)
+			c.pos++
+		}
+		digits()
+	}
+
+	return nil
+}
+
+// Converte un identificatore: le chiavi vengono quotate, le parole chiave restano invariate;
+// ogni altro identificatore in posizione di valore è un errore.
+func (c *json5Converter) identifier() error {
+	start := c.pos
+
+	for c.pos < len(c.src) {
+		r, size := utf8.DecodeRune(c.src[c.pos:])
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		c.pos += size
+	}
+
+	ident := string(c.src[start:c.pos])
+
+	if c.keyFollows() {
+		c.emit(`"`+ident+`"`, start)
+		return nil
+	}
+
+	switch ident {
+	case "true", "false", "null":
+		c.emit(ident, start)
+
+	case "Infinity", "NaN":
+		c.pos = start
+		return c.errorf("Infinity and NaN are not supported")
+
+	default:
+		// Le stringhe devono essere tra apici anche in Json5.
+		c.pos = start
+		return c.errorf("invalid value %q, strings must be quoted", ident)
+	}
+
+	return nil
+}
+
+// Verifica se la posizione corrente, a meno di spazi e commenti, è seguita da ':',
+// ovvero se l'identificatore appena letto è una chiave.
+func (c *json5Converter) keyFollows() bool {
+	pos := c.pos
+
+	for pos < len(c.src) {
+		r, size := utf8.DecodeRune(c.src[pos:])
+
+		switch {
+		case r == ':':
+			return true
+
+		case unicode.IsSpace(r) || r == '\ufeff' || unicode.Is(unicode.Zs, r):
+			pos += size
+
+		case bytes.HasPrefix(c.src[pos:], []byte("//")):
+			end := bytes.IndexByte(c.src[pos:], '\n')
+			if end < 0 {
+				return false
+			}
+			pos += end
+
+		case bytes.HasPrefix(c.src[pos:], []byte("/*")):
+			end := bytes.Index(c.src[pos+2:], []byte("*/"))
+			if end < 0 {
+				return false
+			}
+			pos += end + 4
+
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// Scrive il valore in Json5, con l'indentazione indicata.
+func writeJson5(b *bytes.Buffer, v interface{}, indent string) {
+	switch vv := v.(type) {
+	case *ordered.OrderedMap:
+		var pairs []*ordered.KVPair
+		iter := vv.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+			pairs = append(pairs, pair)
+		}
+
+		if len(pairs) == 0 {
+			b.WriteString("{}")
+			return
+		}
+
+		b.WriteString("{\n")
+		for _, pair := range pairs {
+			b.WriteString(indent + "\t")
+			b.WriteString(Json5Key(pair.Key))
+			b.WriteString(": ")
+			writeJson5(b, pair.Value, indent+"\t")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "}")
+
+	case []interface{}:
+		if len(vv) == 0 {
+			b.WriteString("[]")
+			return
+		}
+
+		b.WriteString("[\n")
+		for _, elt := range vv {
+			b.WriteString(indent + "\t")
+			writeJson5(b, elt, indent+"\t")
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+
+	case string:
+		b.WriteString(Json5String(vv))
+
+	case nil:
+		b.WriteString("null")
+
+	default:
+		fmt.Fprintf(b, "%v", vv)
+	}
+}
+
+// Ritorna la chiave Json5: non quotata se è un identificatore valido, altrimenti tra apici singoli.
+func Json5Key(key string) string {
+	for i, r := range key {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return Json5String(key)
+		}
+	}
+
+	switch key {
+	case "", "true", "false", "null", "Infinity", "NaN":
+		return Json5String(key)
+	}
+
+	return key
+}
+
+// Ritorna la stringa Json5 tra apici singoli.
+func Json5String(s string) string {
+	var b strings.Builder
+
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\u2028', '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('\'')
+
+	return b.String()
+}
@@ -3,7 +3,9 @@ package parsers
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 )
 
 func LoadJsoncFile(filename string, data interface{}) error {
@@ -32,92 +34,299 @@ func LoadJsonc(bb []byte, data interface{}) error {
 	return nil
 }
 
-// Original work: https://github.com/muhammadmuzzammil1998/jsonc/blob/master/translator.go
-// MIT License
-// Copyright (c) 2019 Muhammad Muzzammil
-
-const (
-	ESCAPE   = 92
-	QUOTE    = 34
-	SPACE    = 32
-	TAB      = 9
-	NEWLINE  = 10
-	ASTERISK = 42
-	SLASH    = 47
-)
-
+// Converte da Jsonc a Json mantenendo la posizione di ogni carattere,
+// in modo che gli offset degli errori del decoder corrispondano al documento originale:
+// i commenti e le virgole finali di oggetti e array sono sostituiti da spazi
+// (mantenendo i newline dei commenti multiriga).
 func translate(s []byte) []byte {
-	var (
-		i       int
-		quote   bool
-		escaped bool
-	)
 	j := make([]byte, len(s))
-	comment := &commentData{}
-	for _, ch := range s {
-		if ch == ESCAPE || escaped {
-			j[i] = ch
+	copy(j, s)
+
+	blank := func(from, to int) {
+		for k := from; k < to; k++ {
+			if j[k] != '\n' && j[k] != '\r' {
+				j[k] = ' '
+			}
+		}
+	}
+
+	// Posizione dell'ultima virgola non ancora seguita da un valore.
+	comma := -1
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+
+		switch {
+		case ch == '"':
+			// Stringa: copiata invariata.
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			comma = -1
+
+		case ch == '/' && i+1 < len(s) && s[i+1] == '/':
+			end := bytes.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			blank(i, i+end)
+			i += end
+
+		case ch == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := bytes.Index(s[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(s) - i
+			} else {
+				end += 4
+			}
+			blank(i, i+end)
+			i += end
+
+		case ch == ',':
+			comma = i
+			i++
+
+		case ch == '}' || ch == ']':
+			if comma >= 0 {
+				j[comma] = ' '
+			}
+			comma = -1
+			i++
+
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+
+		default:
+			comma = -1
 			i++
-			escaped = !escaped
-			continue
 		}
-		if ch == QUOTE {
-			quote = !quote
+	}
+
+	return j
+}
+
+// Imposta il valore al percorso indicato nel documento Json o Jsonc,
+// mantenendone commenti, ordine e formattazione (vedi edit.go).
+func SetJsonc(bb []byte, path string, value interface{}) ([]byte, error) {
+	segments, err := splitKeyPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := orderedData(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Posizioni sul documento privo di commenti e virgole finali.
+	j := translate(bb)
+	unit := detectIndent(bb, "\t")
+
+	pos := skipJsonSpaces(j, 0)
+	if pos >= len(j) {
+		// Documento vuoto.
+		nested, err := nestedValue(segments, v)
+		if err != nil {
+			return nil, err
 		}
-		if (ch == SPACE || ch == TAB) && !quote {
-			continue
+		text, err := renderJson(nested, "", unit)
+		if err != nil {
+			return nil, err
+		}
+		return splice(bb, pos, pos, text+"\n"), nil
+	}
+
+	// Valori su una sola riga all'interno di oggetti o array su una sola riga.
+	inline := false
+
+	for i, segment := range segments {
+		if segment.index < 0 && j[pos] != '{' {
+			return nil, fmt.Errorf("%s is not an object", joinSegments(segments[:i]))
+		}
+		if segment.index >= 0 && j[pos] != '[' {
+			return nil, fmt.Errorf("%s is not an array", joinSegments(segments[:i]))
+		}
+
+		items, end, err := jsonItems(j, pos)
+		if err != nil {
+			return nil, err
 		}
-		if ch == NEWLINE {
-			if comment.isSingleLined {
-				comment.stop()
+
+		found := -1
+		for k, item := range items {
+			if segment.index < 0 && strings.EqualFold(item.key, segment.key) || segment.index == k {
+				found = k
+				break
 			}
-			continue
 		}
-		if quote && !comment.startted {
-			j[i] = ch
-			i++
+		if found >= 0 {
+			inline = bytes.IndexByte(j[pos:end], '\n') < 0
+			pos = items[found].start
 			continue
 		}
-		if comment.startted {
-			if ch == ASTERISK {
-				comment.canEnd = true
-				continue
+
+		// Chiave o elemento da inserire.
+		if segment.index > len(items) {
+			return nil, fmt.Errorf("index out of range at %s", joinSegments(segments[:i+1]))
+		}
+
+		nested, err := nestedValue(segments[i+1:], v)
+		if err != nil {
+			return nil, err
+		}
+
+		return jsoncInsert(bb, pos, end, items, segment, nested, unit)
+	}
+
+	end, err := jsonValueEnd(j, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	if inline {
+		text, err = renderJsonInline(v)
+	} else {
+		text, err = renderJson(v, lineIndent(bb, pos), unit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return splice(bb, pos, end, text), nil
+}
+
+// Membro di un oggetto o elemento di un array Json.
+type jsonItem struct {
+	key        string // Chiave dei membri degli oggetti.
+	start, end int    // Posizione del valore.
+}
+
+// Ritorna i membri dell'oggetto o gli elementi dell'array che inizia in pos
+// e la posizione del carattere di chiusura.
+func jsonItems(j []byte, pos int) ([]jsonItem, int, error) {
+	var items []jsonItem
+
+	isObject := j[pos] == '{'
+	closing := byte(']')
+	if isObject {
+		closing = '}'
+	}
+
+	i := skipJsonSpaces(j, pos+1)
+	for i < len(j) && j[i] != closing {
+		var item jsonItem
+
+		if isObject {
+			end, err := jsonValueEnd(j, i)
+			if err != nil {
+				return nil, 0, err
 			}
-			if comment.canEnd && ch == SLASH {
-				comment.stop()
-				continue
+			err = json.Unmarshal(j[i:end], &item.key)
+			if err != nil {
+				return nil, 0, &SyntaxError{Msg: "invalid object key", Offset: int64(i)}
 			}
-			comment.canEnd = false
-			continue
+
+			i = skipJsonSpaces(j, end)
+			if i >= len(j) || j[i] != ':' {
+				return nil, 0, &SyntaxError{Msg: "expected ':' after object key", Offset: int64(i)}
+			}
+			i = skipJsonSpaces(j, i+1)
 		}
-		if comment.canStart && (ch == ASTERISK || ch == SLASH) {
-			comment.start(ch)
-			continue
+
+		end, err := jsonValueEnd(j, i)
+		if err != nil {
+			return nil, 0, err
 		}
-		if ch == SLASH {
-			comment.canStart = true
-			continue
+		item.start, item.end = i, end
+		items = append(items, item)
+
+		i = skipJsonSpaces(j, end)
+		if i < len(j) && j[i] == ',' {
+			i = skipJsonSpaces(j, i+1)
 		}
-		j[i] = ch
-		i++
 	}
-	return j[:i]
+	if i >= len(j) {
+		return nil, 0, &SyntaxError{Msg: "unexpected end of input", Offset: int64(i)}
+	}
+
+	return items, i, nil
 }
 
-type commentData struct {
-	canStart      bool
-	canEnd        bool
-	startted      bool
-	isSingleLined bool
-	//endLine       int
+// Inserisce la chiave o l'elemento in coda all'oggetto o all'array compreso tra start ed end,
+// dopo l'ultimo valore ed eventuali commenti sulla stessa riga.
+func jsoncInsert(bb []byte, start, end int, items []jsonItem, segment pathSegment, v interface{}, unit string) ([]byte, error) {
+	indent := lineIndent(bb, start)
+	childIndent := indent + unit
+	multiline := true
+
+	if len(items) > 0 {
+		last := items[len(items)-1]
+		childIndent = lineIndent(bb, last.start)
+		multiline = lineEnd(bb, last.end) < end
+	}
+
+	var text string
+	var err error
+	if multiline {
+		text, err = renderJson(v, childIndent, unit)
+	} else {
+		text, err = renderJsonInline(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if segment.index < 0 {
+		key, err := renderJson(segment.key, "", "")
+		if err != nil {
+			return nil, err
+		}
+		text = key + ": " + text
+	}
+
+	switch {
+	case len(items) == 0 && len(bytes.TrimSpace(bb[start+1:end])) == 0:
+		return splice(bb, start+1, end, "\n"+childIndent+text+"\n"+indent), nil
+
+	case len(items) == 0:
+		// Solo commenti.
+		return splice(bb, start+1, start+1, "\n"+childIndent+text), nil
+
+	case !multiline:
+		return splice(bb, items[len(items)-1].end, items[len(items)-1].end, ", "+text), nil
+	}
+
+	last := items[len(items)-1]
+	at := lineEnd(bb, last.end)
+	bb = splice(bb, at, at, "\n"+childIndent+text)
+
+	// Virgola dopo l'ultimo valore, se non già presente (anche come virgola finale).
+	if !bytes.HasPrefix(bytes.TrimLeft(bb[last.end:at], " \t"), []byte(",")) {
+		bb = splice(bb, last.end, last.end, ",")
+	}
+
+	return bb, nil
 }
 
-func (c *commentData) stop() {
-	c.startted = false
-	c.canStart = false
+// Ritorna la posizione successiva al valore Json che inizia in pos.
+func jsonValueEnd(j []byte, pos int) (int, error) {
+	d := json.NewDecoder(bytes.NewReader(j[pos:]))
+
+	var raw json.RawMessage
+	err := d.Decode(&raw)
+	if err != nil {
+		return 0, &SyntaxError{Msg: err.Error(), Offset: int64(pos)}
+	}
+
+	return pos + int(d.InputOffset()), nil
 }
 
-func (c *commentData) start(ch byte) {
-	c.startted = true
-	c.isSingleLined = ch == SLASH
+func skipJsonSpaces(j []byte, pos int) int {
+	for pos < len(j) && (j[pos] == ' ' || j[pos] == '\t' || j[pos] == '\n' || j[pos] == '\r') {
+		pos++
+	}
+	return pos
 }
@@ -0,0 +1,354 @@
+package parsers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// Le funzioni *KeyLines ritornano, per ciascuna chiave presente nel documento,
+// la riga (a partire da 1) in cui è definita.
+// Le chiavi sono percorsi in minuscolo, con i livelli separati da '.'
+// e gli indici degli elementi di array tra '[]', ad es. "main.paramint", "users[1].name".
+
+// Chiavi presenti in un documento Json o Jsonc.
+func JsoncKeyLines(bb []byte) (map[string]int, error) {
+	s := &jsoncScanner{data: bb, line: 1, lines: make(map[string]int)}
+
+	s.skipSpaces()
+	err := s.value("")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.lines, nil
+}
+
+// Chiavi presenti in un documento Yaml.
+func YamlKeyLines(bb []byte) (map[string]int, error) {
+	var doc yaml.Node
+
+	err := yaml.Unmarshal(bb, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]int)
+	yamlNodeLines(&doc, "", lines)
+
+	return lines, nil
+}
+
+func yamlNodeLines(n *yaml.Node, path string, lines map[string]int) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			yamlNodeLines(c, path, lines)
+		}
+
+	case yaml.AliasNode:
+		yamlNodeLines(n.Alias, path, lines)
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+
+			if k.Tag == "!!merge" {
+				yamlNodeLines(v, path, lines)
+				continue
+			}
+
+			key := joinKeyPath(path, k.Value)
+			lines[key] = k.Line
+			yamlNodeLines(v, key, lines)
+		}
+
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			key := fmt.Sprintf("%s[%d]", path, i)
+			lines[key] = c.Line
+			yamlNodeLines(c, key, lines)
+		}
+	}
+}
+
+// Chiavi presenti in un documento Toml.
+func TomlKeyLines(bb []byte) (map[string]int, error) {
+	lines := make(map[string]int)
+	arrayTables := make(map[string]int)
+
+	p := unstable.Parser{}
+	p.Reset(bb)
+
+	table := ""
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			key := ""
+			line := 0
+
+			it := expr.Key()
+			for it.Next() {
+				k := it.Node()
+				line = p.Shape(k.Raw).Start.Line
+
+				// I livelli che sono array di tabelle fanno riferimento al loro ultimo elemento.
+				if n, ok := arrayTables[key]; ok && key != "" {
+					key = fmt.Sprintf("%s[%d]", key, n-1)
+				}
+				key = joinKeyPath(key, string(k.Data))
+			}
+
+			if expr.Kind == unstable.ArrayTable {
+				// Ogni [[tabella]] aggiunge un elemento all'array.
+				arrayTables[key]++
+				lines[key] = line
+				key = fmt.Sprintf("%s[%d]", key, arrayTables[key]-1)
+			}
+			table = key
+			lines[table] = line
+
+		case unstable.KeyValue:
+			key, line := tomlKey(&p, expr, table)
+			lines[key] = line
+			tomlValueLines(&p, expr.Value(), key, line, lines)
+		}
+	}
+
+	if p.Error() != nil {
+		return nil, p.Error()
+	}
+
+	return lines, nil
+}
+
+// Ritorna il percorso completo e la riga della chiave del nodo.
+func tomlKey(p *unstable.Parser, n *unstable.Node, parent string) (string, int) {
+	key := parent
+	line := 0
+
+	it := n.Key()
+	for it.Next() {
+		k := it.Node()
+		key = joinKeyPath(key, string(k.Data))
+		line = p.Shape(k.Raw).Start.Line
+	}
+
+	return key, line
+}
+
+// Riporta le chiavi dei valori composti; gli elementi degli array ereditano la riga della chiave.
+func tomlValueLines(p *unstable.Parser, n *unstable.Node, path string, line int, lines map[string]int) {
+	switch n.Kind {
+	case unstable.InlineTable:
+		it := n.Children()
+		for it.Next() {
+			kv := it.Node()
+			key, line := tomlKey(p, kv, path)
+			lines[key] = line
+			tomlValueLines(p, kv.Value(), key, line, lines)
+		}
+
+	case unstable.Array:
+		i := 0
+		it := n.Children()
+		for it.Next() {
+			elt := it.Node()
+			if elt.Kind == unstable.Comment {
+				continue
+			}
+			key := fmt.Sprintf("%s[%d]", path, i)
+			lines[key] = line
+			tomlValueLines(p, elt, key, line, lines)
+			i++
+		}
+	}
+}
+
+// Errore di sintassi, con la posizione nel documento originale.
+type SyntaxError struct {
+	Msg    string
+	Offset int64 // Offset in byte del carattere che ha generato l'errore.
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Msg
+}
+
+// Scanner minimale di documenti Json con commenti, finalizzato alla sola individuazione delle chiavi.
+type jsoncScanner struct {
+	data  []byte
+	pos   int
+	line  int
+	lines map[string]int
+}
+
+func (s *jsoncScanner) value(path string) error {
+	if s.pos >= len(s.data) {
+		return s.errorf("unexpected end of input")
+	}
+
+	switch s.data[s.pos] {
+	case '{':
+		return s.object(path)
+
+	case '[':
+		return s.array(path)
+
+	case '"':
+		_, err := s.str()
+		return err
+
+	default:
+		// Scalare: numero, booleano o null.
+		start := s.pos
+		for s.pos < len(s.data) && !strings.ContainsRune(",:{}[] \t\r\n/", rune(s.data[s.pos])) {
+			s.pos++
+		}
+		if s.pos == start {
+			return s.errorf("unexpected character '%c'", s.data[s.pos])
+		}
+		return nil
+	}
+}
+
+func (s *jsoncScanner) object(path string) error {
+	s.pos++ // {
+
+	for {
+		s.skipSpaces()
+		if s.pos >= len(s.data) {
+			return s.errorf("unexpected end of input in object")
+		}
+		if s.data[s.pos] == '}' {
+			s.pos++
+			return nil
+		}
+
+		line := s.line
+		k, err := s.str()
+		if err != nil {
+			return err
+		}
+
+		key := joinKeyPath(path, k)
+		s.lines[key] = line
+
+		s.skipSpaces()
+		if s.pos >= len(s.data) || s.data[s.pos] != ':' {
+			return s.errorf("expected ':' after object key")
+		}
+		s.pos++
+
+		s.skipSpaces()
+		err = s.value(key)
+		if err != nil {
+			return err
+		}
+
+		s.skipSpaces()
+		if s.pos < len(s.data) && s.data[s.pos] == ',' {
+			s.pos++
+		}
+	}
+}
+
+func (s *jsoncScanner) array(path string) error {
+	s.pos++ // [
+
+	for i := 0; ; i++ {
+		s.skipSpaces()
+		if s.pos >= len(s.data) {
+			return s.errorf("unexpected end of input in array")
+		}
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return nil
+		}
+
+		key := fmt.Sprintf("%s[%d]", path, i)
+		s.lines[key] = s.line
+
+		err := s.value(key)
+		if err != nil {
+			return err
+		}
+
+		s.skipSpaces()
+		if s.pos < len(s.data) && s.data[s.pos] == ',' {
+			s.pos++
+		}
+	}
+}
+
+// Legge una stringa tra doppi apici ritornandone il contenuto (escape non risolti).
+func (s *jsoncScanner) str() (string, error) {
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return "", s.errorf("expected string")
+	}
+
+	start := s.pos + 1
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			return string(s.data[start : s.pos-1]), nil
+		case '\n':
+			s.line++
+		}
+	}
+
+	return "", s.errorf("unexpected end of input in string")
+}
+
+func (s *jsoncScanner) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Offset: int64(s.pos)}
+}
+
+// Salta spazi e commenti.
+func (s *jsoncScanner) skipSpaces() {
+	for s.pos < len(s.data) {
+		ch := s.data[s.pos]
+
+		switch {
+		case ch == '\n':
+			s.line++
+			s.pos++
+
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			s.pos++
+
+		case ch == '/' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '/':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' {
+				s.pos++
+			}
+
+		case ch == '/' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '*':
+			s.pos += 2
+			for s.pos < len(s.data) && !(s.data[s.pos] == '*' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '/') {
+				if s.data[s.pos] == '\n' {
+					s.line++
+				}
+				s.pos++
+			}
+			s.pos += 2
+
+		default:
+			return
+		}
+	}
+}
+
+func joinKeyPath(path, key string) string {
+	key = strings.ToLower(key)
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
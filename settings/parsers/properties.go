@@ -0,0 +1,430 @@
+package parsers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Java properties (.properties): coppie "chiave=valore", "chiave: valore" o "chiave valore",
+// le cui chiavi corrispondono ai percorsi dei campi separati da '.',
+// ad es. main.paramSub.paramMap.key=value imposta la chiave "key" della mappa Main.ParamSub.ParamMap.
+// Gli elementi degli elenchi di strutture sono indicati dall'indice, es. users.0.name;
+// un '.' all'interno di una chiave di mappa va indicato come "\.".
+// Le righe che iniziano per '#' o '!' sono commenti; una riga terminante con '\' prosegue nella successiva.
+// Le sequenze di escape sono quelle di java.util.Properties (\t, \n, \uXXXX, ...);
+// in salvataggio i caratteri non ASCII sono codificati come \uXXXX, per la compatibilità con
+// Properties.load(InputStream) che legge il documento come ISO-8859-1.
+// Per la conversione dei valori vedi flat.go.
+
+func LoadPropertiesFile(filename string, data interface{}) error {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	return LoadProperties(bb, data)
+}
+
+func LoadProperties(bb []byte, data interface{}) error {
+	entries, err := parseProperties(bb)
+	if err != nil {
+		return err
+	}
+
+	tree := ordered.NewOrderedMap()
+	for _, entry := range entries {
+		err = setFlatPath(tree, entry.path, entry.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(entry.path, "."), err)
+		}
+	}
+
+	return decodeFlat(tree, data)
+}
+
+func SavePropertiesFile(filename string, data interface{}) error {
+	b, err := SaveProperties(data)
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(filename, b, nil)
+}
+
+// Codifica in properties: i gruppi di chiavi di primo livello sono separati da una riga vuota.
+func SaveProperties(data interface{}) ([]byte, error) {
+	v, err := orderedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(*ordered.OrderedMap)
+	if !ok {
+		return nil, errors.New("properties document must be an object")
+	}
+
+	var b bytes.Buffer
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+
+		_, nested := pair.Value.(*ordered.OrderedMap)
+		if list, isList := pair.Value.([]interface{}); isList && len(list) > 0 {
+			_, nested = list[0].(*ordered.OrderedMap)
+		}
+		if nested && b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		err = writeProperties(&b, PropertiesKey(pair.Key), pair.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// Chiavi presenti in un documento properties (vedi JsoncKeyLines).
+func PropertiesKeyLines(bb []byte) (map[string]int, error) {
+	entries, err := parseProperties(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]int)
+	for _, entry := range entries {
+		path := ""
+		for _, segment := range entry.path {
+			path = joinFlatPath(path, segment)
+			if _, ok := lines[path]; !ok {
+				lines[path] = entry.line
+			}
+		}
+	}
+
+	return lines, nil
+}
+
+// Direttiva di inclusione di un documento properties, come chiave di primo livello;
+// il valore è un elenco di percorsi separati da virgole.
+// Il documento viene ritornato con le righe della direttiva vuote, mantenendo le righe.
+func PropertiesIncludes(bb []byte) (includes []string, rest []byte, err error) {
+	entries, err := parseProperties(bb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := make(map[int]bool)
+	for _, entry := range entries {
+		if len(entry.path) != 1 || !strings.EqualFold(entry.path[0], IncludeKey) {
+			continue
+		}
+
+		for _, item := range splitList(entry.value) {
+			includes = append(includes, unquoteValue(item))
+		}
+		for line := entry.line; line <= entry.lastLine; line++ {
+			lines[line] = true
+		}
+	}
+	if len(lines) == 0 {
+		return nil, bb, nil
+	}
+
+	rows := bytes.SplitAfter(bb, []byte("\n"))
+	rest = make([]byte, 0, len(bb))
+	for i, row := range rows {
+		if lines[i+1] {
+			row = row[len(bytes.TrimRight(row, "\r\n")):]
+		}
+		rest = append(rest, row...)
+	}
+
+	return includes, rest, nil
+}
+
+// Ritorna la chiave properties, con gli escape di separatori, '.', e caratteri speciali.
+func PropertiesKey(key string) string {
+	var b strings.Builder
+
+	for i, r := range key {
+		switch r {
+		case '.', '=', ':', ' ', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '#', '!':
+			if i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			writePropertiesRune(&b, r)
+		}
+	}
+
+	return b.String()
+}
+
+// Ritorna il valore properties, con gli escape di '\', degli spazi iniziali e dei caratteri non ASCII.
+// Il valore deve essere già in forma testuale (vedi flatText).
+func PropertiesValue(s string) string {
+	var b strings.Builder
+
+	leading := true
+	for _, r := range s {
+		switch {
+		case r == ' ' && leading:
+			b.WriteString(`\ `)
+			continue
+		case r == '\\':
+			b.WriteString(`\\`)
+		default:
+			writePropertiesRune(&b, r)
+		}
+		leading = false
+	}
+
+	return b.String()
+}
+
+func writePropertiesRune(b *strings.Builder, r rune) {
+	switch {
+	case r == '\t':
+		b.WriteString(`\t`)
+	case r == '\n':
+		b.WriteString(`\n`)
+	case r == '\r':
+		b.WriteString(`\r`)
+	case r == '\f':
+		b.WriteString(`\f`)
+	case r < 0x20 || r > 0x7e:
+		if r > 0xffff {
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(b, `\u%04x\u%04x`, r1, r2)
+		} else {
+			fmt.Fprintf(b, `\u%04x`, r)
+		}
+	default:
+		b.WriteRune(r)
+	}
+}
+
+// Voce di un documento properties.
+type propertiesEntry struct {
+	path     []string // Chiave suddivisa nei livelli.
+	value    string
+	line     int // Riga della chiave.
+	lastLine int // Ultima riga del valore, per le righe che proseguono.
+}
+
+// Legge le voci del documento properties.
+func parseProperties(bb []byte) ([]propertiesEntry, error) {
+	var entries []propertiesEntry
+
+	doc := string(bb)
+	offset := 0
+	if strings.HasPrefix(doc, "\ufeff") {
+		offset = len("\ufeff")
+		doc = doc[offset:]
+	}
+
+	rows := strings.SplitAfter(doc, "\n")
+
+	for i := 0; i < len(rows); i++ {
+		start := offset
+		offset += len(rows[i])
+
+		row := strings.TrimLeft(strings.TrimRight(rows[i], "\r\n"), " \t\f")
+		start += strings.Index(rows[i], row)
+
+		if row == "" || row[0] == '#' || row[0] == '!' {
+			continue
+		}
+
+		// Righe che proseguono nella successiva.
+		entry := propertiesEntry{line: i + 1, lastLine: i + 1}
+		for continues(row) && i+1 < len(rows) {
+			i++
+			offset += len(rows[i])
+			row = row[:len(row)-1] + strings.TrimLeft(strings.TrimRight(rows[i], "\r\n"), " \t\f")
+			entry.lastLine = i + 1
+		}
+		if continues(row) {
+			row = row[:len(row)-1]
+		}
+
+		// Fine della chiave: primo separatore non preceduto da escape.
+		end := len(row)
+		for j := 0; j < len(row); j++ {
+			if row[j] == '\\' {
+				j++
+				continue
+			}
+			if row[j] == '=' || row[j] == ':' || row[j] == ' ' || row[j] == '\t' || row[j] == '\f' {
+				end = j
+				break
+			}
+		}
+
+		rawKey := row[:end]
+		value := strings.TrimLeft(row[end:], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+
+		errorf := func(format string, args ...interface{}) error {
+			return &SyntaxError{Msg: fmt.Sprintf(format, args...), Offset: int64(start)}
+		}
+
+		if rawKey == "" {
+			return nil, errorf("missing key")
+		}
+
+		// Livelli della chiave, separati da '.' non preceduti da escape.
+		segStart := 0
+		for j := 0; j <= len(rawKey); j++ {
+			if j < len(rawKey) && rawKey[j] == '\\' {
+				j++
+				continue
+			}
+			if j < len(rawKey) && rawKey[j] != '.' {
+				continue
+			}
+
+			segment, err := unescapeProperties(rawKey[segStart:j])
+			if err != nil {
+				return nil, errorf("%s", err)
+			}
+			if segment == "" {
+				return nil, errorf("invalid key %s", rawKey)
+			}
+
+			entry.path = append(entry.path, segment)
+			segStart = j + 1
+		}
+
+		var err error
+		entry.value, err = unescapeProperties(value)
+		if err != nil {
+			return nil, errorf("%s", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Verifica se la riga prosegue nella successiva, cioè se termina con un numero dispari di '\'.
+func continues(row string) bool {
+	n := len(row) - len(strings.TrimRight(row, `\`))
+	return n%2 == 1
+}
+
+// Interpreta le sequenze di escape di java.util.Properties.
+func unescapeProperties(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	var pending rune // Primo elemento di una coppia surrogata.
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", errors.New("malformed \\uxxxx encoding")
+			}
+			u, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", errors.New("malformed \\uxxxx encoding")
+			}
+			i += 4
+
+			r := rune(u)
+			switch {
+			case utf16.IsSurrogate(r) && pending == 0:
+				pending = r
+				continue
+			case pending != 0:
+				r = utf16.DecodeRune(pending, r)
+				pending = 0
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Scrive le chiavi corrispondenti al valore.
+//   - key: chiave del valore, con gli escape (vedi PropertiesKey).
+func writeProperties(b *bytes.Buffer, key string, v interface{}) error {
+	switch vv := v.(type) {
+	case *ordered.OrderedMap:
+		iter := vv.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+
+			err := writeProperties(b, key+"."+PropertiesKey(pair.Key), pair.Value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []interface{}:
+		if len(vv) > 0 {
+			if _, isMap := vv[0].(*ordered.OrderedMap); isMap {
+				for i, elt := range vv {
+					err := writeProperties(b, fmt.Sprintf("%s.%d", key, i), elt)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+	}
+
+	text, ok := flatText(v, false)
+	if !ok {
+		return fmt.Errorf("unsupported value for key %s", key)
+	}
+
+	b.WriteString(key + "=" + PropertiesValue(text) + "\n")
+
+	return nil
+}
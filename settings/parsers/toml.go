@@ -2,9 +2,16 @@ package parsers
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gitlab.com/c0b/go-ordered-json"
 )
 
 func LoadTomlFile(filename string, data interface{}) error {
@@ -19,6 +26,12 @@ func LoadTomlFile(filename string, data interface{}) error {
 func LoadToml(bb []byte, data interface{}) error {
 	r := bytes.NewReader(bb)
 
+	// Gli array di tabelle sostituiscono gli slice esistenti, come per gli altri formati,
+	// anziché aggiungervi elementi.
+	for _, keys := range tomlArrayTables(bb) {
+		tomlResetSlice(reflect.ValueOf(data), keys)
+	}
+
 	d := toml.NewDecoder(r)
 	d.DisallowUnknownFields()
 
@@ -30,23 +43,101 @@ func LoadToml(bb []byte, data interface{}) error {
 	return nil
 }
 
-func SaveTomlFile(filename string, data interface{}) error {
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
+// Ritorna le chiavi degli array di tabelle di primo livello (non contenuti in altri array) del documento.
+func tomlArrayTables(bb []byte) [][]string {
+	var tables [][]string
+	arrays := make(map[string]bool)
+
+	p := unstable.Parser{}
+	p.Reset(bb)
+
+	for p.NextExpression() {
+		expr := p.Expression()
+		if expr.Kind != unstable.ArrayTable {
+			continue
+		}
+
+		var keys []string
+		path := ""
+		nested := false
+
+		it := expr.Key()
+		for it.Next() {
+			if arrays[path] {
+				nested = true
+			}
+			k := string(it.Node().Data)
+			keys = append(keys, k)
+			path = joinKeyPath(path, k)
+		}
+
+		if !nested && !arrays[path] {
+			arrays[path] = true
+			tables = append(tables, keys)
+		}
+	}
+
+	return tables
+}
+
+// Azzera lo slice alle chiavi indicate, individuate come dal decoder (senza distinzione tra maiuscole e minuscole).
+func tomlResetSlice(v reflect.Value, keys []string) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if len(keys) == 0 {
+		if v.Kind() == reflect.Slice && v.CanSet() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return
 	}
-	defer f.Close()
 
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+			if name == "-" || !field.IsExported() && !field.Anonymous {
+				continue
+			}
+
+			switch {
+			case field.Anonymous && name == "":
+				tomlResetSlice(v.Field(i), keys)
+			case strings.EqualFold(name, keys[0]) || name == "" && strings.EqualFold(field.Name, keys[0]):
+				tomlResetSlice(v.Field(i), keys[1:])
+			}
+		}
+
+	case reflect.Map:
+		key := reflect.ValueOf(keys[0])
+		if len(keys) == 1 && v.Type().Key().Kind() == reflect.String && v.Type().Elem().Kind() == reflect.Slice &&
+			v.MapIndex(key.Convert(v.Type().Key())).IsValid() {
+			v.SetMapIndex(key.Convert(v.Type().Key()), reflect.Zero(v.Type().Elem()))
+		}
+	}
+}
+
+func SaveTomlFile(filename string, data interface{}) error {
 	b, err := SaveToml(data)
 	if err != nil {
 		return err
 	}
-	err = os.WriteFile(filename, b, 0666)
 
-	return err
+	return WriteFile(filename, b, nil)
 }
 
 func SaveToml(data interface{}) ([]byte, error) {
+	// Mappa ordinata, ad es. delle sole differenze dai default: l'encoder ordina le chiavi.
+	if m, ok := data.(*ordered.OrderedMap); ok {
+		data = plainData(m)
+	}
+
 	bb, err := toml.Marshal(data)
 	if err != nil {
 		return nil, err
@@ -54,3 +145,447 @@ func SaveToml(data interface{}) ([]byte, error) {
 
 	return bb, nil
 }
+
+// Imposta il valore al percorso indicato nel documento Toml,
+// mantenendone commenti, ordine e formattazione (vedi edit.go).
+// I valori sono scritti in forma inline; le nuove tabelle sono aggiunte in coda al documento
+// come sezioni [tabella] o [[array]]. I valori inline esistenti (array e tabelle inline)
+// modificati al loro interno vengono riscritti per intero.
+func SetToml(bb []byte, path string, value interface{}) ([]byte, error) {
+	segments, err := splitKeyPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := orderedData(value)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := scanToml(bb)
+	if err != nil {
+		return nil, err
+	}
+
+	key := ""
+	for i, segment := range segments {
+		parent := key
+		if segment.index >= 0 {
+			key = fmt.Sprintf("%s[%d]", key, segment.index)
+		} else {
+			key = joinKeyPath(key, segment.key)
+		}
+
+		if kv, ok := doc.values[key]; ok {
+			return doc.setValue(kv, segments[i+1:], v)
+		}
+		if _, ok := doc.objects[key]; ok {
+			continue
+		}
+		if _, ok := doc.arrays[key]; ok {
+			if i+1 == len(segments) || segments[i+1].index < 0 {
+				return nil, fmt.Errorf("%s is an array of tables", joinSegments(segments[:i+1]))
+			}
+			continue
+		}
+
+		nested, err := nestedValue(segments[i+1:], v)
+		if err != nil {
+			return nil, err
+		}
+
+		if segment.index >= 0 {
+			// Elemento da aggiungere a un array di tabelle.
+			if n, ok := doc.arrays[parent]; ok && segment.index == n {
+				return doc.appendTable(parent, nested, true)
+			}
+			if kv, ok := doc.values[parent]; ok {
+				return doc.setValue(kv, segments[i:], v)
+			}
+			return nil, fmt.Errorf("index out of range at %s", joinSegments(segments[:i+1]))
+		}
+
+		obj, ok := doc.objects[parent]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a table", joinSegments(segments[:i]))
+		}
+		return doc.insert(obj, segment.key, nested)
+	}
+
+	return nil, fmt.Errorf("%s is a table", path)
+}
+
+// Posizioni delle tabelle e dei valori di un documento Toml, per percorso (vedi TomlKeyLines).
+type tomlDoc struct {
+	bb      []byte
+	values  map[string]*tomlValue
+	objects map[string]*tomlObject // Tabelle, comprese quelle definite da chiavi puntate.
+	arrays  map[string]int         // Numero di elementi degli array di tabelle.
+	first   int                    // Inizio della riga della prima intestazione di tabella, -1 se assente.
+}
+
+// Sezione del documento: la radice, una [tabella] o un elemento di [[array]].
+type tomlSection struct {
+	header string // Intestazione come nel documento, es. "main.sub".
+	inList bool   // Sezione di un array di tabelle, o al suo interno.
+	end    int    // Fine della riga dell'ultimo valore o dell'intestazione, -1 per la radice vuota.
+	indent string // Indentazione dell'ultimo valore.
+}
+
+// Tabella, definita da una sezione o da chiavi puntate al suo interno.
+type tomlObject struct {
+	section *tomlSection
+	prefix  string // Chiavi puntate relative alla sezione, es. "sub.", come nel documento.
+}
+
+// Valore di una chiave: posizione nel documento.
+type tomlValue struct {
+	start, end int
+}
+
+func scanToml(bb []byte) (*tomlDoc, error) {
+	doc := &tomlDoc{
+		bb:      bb,
+		values:  make(map[string]*tomlValue),
+		objects: make(map[string]*tomlObject),
+		arrays:  make(map[string]int),
+		first:   -1,
+	}
+
+	p := unstable.Parser{}
+	p.Reset(bb)
+
+	section := &tomlSection{end: -1}
+	doc.objects[""] = &tomlObject{section: section}
+	table := ""
+
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			key := ""
+			var raw []string
+			offset := 0
+			inList := false
+
+			it := expr.Key()
+			for it.Next() {
+				k := it.Node()
+				if offset == 0 {
+					offset = int(k.Raw.Offset)
+				}
+				raw = append(raw, string(p.Raw(k.Raw)))
+
+				// I livelli che sono array di tabelle fanno riferimento al loro ultimo elemento.
+				if n, ok := doc.arrays[key]; ok && key != "" {
+					key = fmt.Sprintf("%s[%d]", key, n-1)
+					inList = true
+				}
+				key = joinKeyPath(key, string(k.Data))
+			}
+
+			if expr.Kind == unstable.ArrayTable {
+				doc.arrays[key]++
+				key = fmt.Sprintf("%s[%d]", key, doc.arrays[key]-1)
+				inList = true
+			}
+
+			if doc.first < 0 {
+				doc.first = bytes.LastIndexByte(bb[:offset], '\n') + 1
+			}
+
+			table = key
+			section = &tomlSection{
+				header: strings.Join(raw, "."),
+				inList: inList,
+				end:    lineEnd(bb, offset),
+			}
+			doc.objects[table] = &tomlObject{section: section}
+
+		case unstable.KeyValue:
+			key := table
+			prefix := ""
+			end := 0
+
+			it := expr.Key()
+			for it.Next() {
+				k := it.Node()
+				if key != table {
+					// Livelli intermedi delle chiavi puntate.
+					if _, ok := doc.objects[key]; !ok {
+						doc.objects[key] = &tomlObject{section: section, prefix: prefix}
+					}
+				}
+				key = joinKeyPath(key, string(k.Data))
+				prefix += string(p.Raw(k.Raw)) + "."
+				end = int(k.Raw.Offset + k.Raw.Length)
+			}
+
+			start := end + bytes.IndexByte(bb[end:], '=') + 1
+			for start < len(bb) && (bb[start] == ' ' || bb[start] == '\t') {
+				start++
+			}
+			valueEnd, err := tomlValueEnd(bb, start)
+			if err != nil {
+				return nil, err
+			}
+
+			doc.values[key] = &tomlValue{start: start, end: valueEnd}
+			section.end = lineEnd(bb, valueEnd)
+			section.indent = lineIndent(bb, start)
+		}
+	}
+
+	if p.Error() != nil {
+		return nil, p.Error()
+	}
+
+	return doc, nil
+}
+
+// Ritorna la posizione successiva al valore che inizia in pos.
+func tomlValueEnd(bb []byte, pos int) (int, error) {
+	if pos >= len(bb) {
+		return 0, &SyntaxError{Msg: "expected value", Offset: int64(pos)}
+	}
+
+	switch c := bb[pos]; c {
+	case '"', '\'':
+		delim := bb[pos : pos+1]
+		if bytes.HasPrefix(bb[pos:], []byte{c, c, c}) {
+			delim = bb[pos : pos+3]
+		}
+		i := pos + len(delim)
+		for i < len(bb) {
+			if c == '"' && bb[i] == '\\' {
+				i += 2
+				continue
+			}
+			if bytes.HasPrefix(bb[i:], delim) {
+				end := i + len(delim)
+				// Fino a due apici finali appartengono alla stringa su più righe.
+				for k := 0; k < 2 && len(delim) == 3 && end < len(bb) && bb[end] == c; k++ {
+					end++
+				}
+				return end, nil
+			}
+			i++
+		}
+		return 0, &SyntaxError{Msg: "unterminated string", Offset: int64(pos)}
+
+	case '[', '{':
+		depth := 0
+		for i := pos; i < len(bb); i++ {
+			switch bb[i] {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			case '"', '\'':
+				end, err := tomlValueEnd(bb, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			case '#':
+				i = lineEnd(bb, i) - 1
+			}
+		}
+		return 0, &SyntaxError{Msg: "unterminated value", Offset: int64(pos)}
+	}
+
+	// Scalare: numero, booleano o data (eventualmente con l'ora separata da uno spazio).
+	i := pos
+	for i < len(bb) && !strings.ContainsRune(" \t\r\n,]}#", rune(bb[i])) {
+		i++
+	}
+	if i-pos == 10 && i+1 < len(bb) && bb[i] == ' ' && bb[i+1] >= '0' && bb[i+1] <= '9' && bytes.Count(bb[pos:i], []byte("-")) == 2 {
+		for i++; i < len(bb) && !strings.ContainsRune(" \t\r\n,]}#", rune(bb[i])); i++ {
+		}
+	}
+
+	return i, nil
+}
+
+// Imposta il valore al percorso relativo al valore esistente kv.
+func (d *tomlDoc) setValue(kv *tomlValue, segments []pathSegment, v interface{}) ([]byte, error) {
+	if len(segments) > 0 {
+		// Modifica all'interno di un valore inline: viene riscritto per intero.
+		var m map[string]interface{}
+		err := toml.Unmarshal(append([]byte("v = "), d.bb[kv.start:kv.end]...), &m)
+		if err != nil {
+			return nil, err
+		}
+		v, err = setTreeValue(m["v"], segments, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	text, err := tomlInline(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return splice(d.bb, kv.start, kv.end, text), nil
+}
+
+// Inserisce la chiave nella tabella obj: le tabelle sono aggiunte come nuove sezioni,
+// se possibile, gli altri valori dopo l'ultimo della sezione.
+func (d *tomlDoc) insert(obj *tomlObject, key string, v interface{}) ([]byte, error) {
+	section := obj.section
+
+	if m, ok := v.(*ordered.OrderedMap); ok && !emptyMap(m) && obj.prefix == "" && !section.inList {
+		header := TomlKey(key)
+		if section.header != "" {
+			header = section.header + "." + header
+		}
+		return d.appendTable(header, m, false)
+	}
+
+	text, err := tomlInline(v)
+	if err != nil {
+		return nil, err
+	}
+	text = obj.prefix + TomlKey(key) + " = " + text
+
+	switch {
+	case section.end >= 0:
+		return splice(d.bb, section.end, section.end, "\n"+section.indent+text), nil
+	case d.first >= 0:
+		// Radice senza valori: prima della prima tabella.
+		return splice(d.bb, d.first, d.first, text+"\n\n"), nil
+	}
+
+	return d.appendText(text), nil
+}
+
+// Aggiunge in coda al documento la sezione [header] o [[header]] con i valori di v.
+//   - header: intestazione come nel documento, o percorso dell'array di tabelle (list);
+func (d *tomlDoc) appendTable(header string, v interface{}, list bool) ([]byte, error) {
+	m, ok := v.(*ordered.OrderedMap)
+	if !ok {
+		return nil, fmt.Errorf("items of arrays of tables must be tables")
+	}
+
+	var b strings.Builder
+	if list {
+		b.WriteString("[[" + d.rawHeader(header) + "]]")
+	} else {
+		b.WriteString("[" + header + "]")
+	}
+
+	iter := m.EntriesIter()
+	for {
+		pair, ok := iter()
+		if !ok {
+			break
+		}
+		text, err := tomlInline(pair.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("\n" + TomlKey(pair.Key) + " = " + text)
+	}
+
+	text := b.String()
+	if len(bytes.TrimSpace(d.bb)) > 0 {
+		text = "\n" + text
+	}
+	return d.appendText(text), nil
+}
+
+// Ritorna l'intestazione come nel documento dell'array di tabelle al percorso indicato.
+func (d *tomlDoc) rawHeader(path string) string {
+	if obj, ok := d.objects[path+"[0]"]; ok {
+		return obj.section.header
+	}
+	return path
+}
+
+// Aggiunge le righe in coda al documento.
+func (d *tomlDoc) appendText(text string) []byte {
+	bb := d.bb
+	if len(bb) > 0 && !bytes.HasSuffix(bb, []byte("\n")) {
+		bb = append(bb, '\n')
+	}
+	return append(bb, text+"\n"...)
+}
+
+// Ritorna la chiave Toml, tra doppi apici se necessario.
+func TomlKey(key string) string {
+	if key != "" && strings.Trim(key, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-") == "" {
+		return key
+	}
+	text, _ := renderJson(key, "", "")
+	return text
+}
+
+// Codifica il valore Toml in forma inline.
+func tomlInline(v interface{}) (string, error) {
+	var items []string
+
+	switch t := v.(type) {
+	case nil:
+		return "", errors.New("null values are not supported by Toml")
+
+	case *ordered.OrderedMap:
+		iter := t.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+			text, err := tomlInline(pair.Value)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, TomlKey(pair.Key)+" = "+text)
+		}
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			text, err := tomlInline(t[k])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, TomlKey(k)+" = "+text)
+		}
+
+	case []interface{}:
+		for _, item := range t {
+			text, err := tomlInline(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+
+	case string:
+		// Stringa base tra doppi apici, con le sequenze di escape compatibili con Json.
+		return renderJson(t, "", "")
+
+	default:
+		// Scalare codificato dall'encoder.
+		bb, err := toml.Marshal(map[string]interface{}{"v": scalarValue(v)})
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(strings.TrimPrefix(string(bb), "v = ")), nil
+	}
+
+	if len(items) == 0 {
+		return "{}", nil
+	}
+	return "{ " + strings.Join(items, ", ") + " }", nil
+}
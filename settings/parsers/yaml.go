@@ -4,15 +4,21 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"gitlab.com/c0b/go-ordered-json"
 	"gopkg.in/yaml.v3"
 )
 
-// Nota: Yaml è case insensitive ma il decoder si comporta male: https://github.com/go-yaml/yaml/issues/123
+// Nota: Yaml è case sensitive e il decoder non consente diversamente: https://github.com/go-yaml/yaml/issues/123
 // Si è preferito comunque uscire leggermente dalla RFC
 // permettendo il case insensitive nei nomi variabili struct,
 // in modo da essere allineati con il comportamento dei decoder di Json e Toml.
+// Il documento viene decodificato tramite il suo albero di nodi, riscrivendo le sole chiavi
+// che corrispondono ai campi delle struct: le chiavi delle map e i valori restano invariati.
 
 func LoadYamlFile(filename string, data interface{}) error {
 	bb, err := os.ReadFile(filename)
@@ -24,36 +30,44 @@ func LoadYamlFile(filename string, data interface{}) error {
 }
 
 func LoadYaml(bb []byte, data interface{}) error {
-	r := bytes.NewReader(lowerKeys(bb))
+	var doc yaml.Node
 
-	d := yaml.NewDecoder(r)
-	d.KnownFields(true)
-
-	err := d.Decode(data)
+	err := yaml.Unmarshal(bb, &doc)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	if doc.Kind == 0 {
+		return nil // documento vuoto
+	}
 
-func SaveYamlFile(filename string, data interface{}) error {
-	f, err := os.Create(filename)
+	err = yamlMatchKeys(&doc, reflect.TypeOf(data), make(map[yamlVisit]bool))
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
+	return doc.Decode(data)
+}
+
+func SaveYamlFile(filename string, data interface{}) error {
 	b, err := SaveYaml(data)
 	if err != nil {
 		return err
 	}
-	err = os.WriteFile(filename, b, 0666)
 
-	return err
+	return WriteFile(filename, b, nil)
 }
 
 func SaveYaml(data interface{}) ([]byte, error) {
+	// Mappa ordinata, ad es. delle sole differenze dai default: codificata come nodo per mantenere l'ordine.
+	if m, ok := data.(*ordered.OrderedMap); ok {
+		n, err := yamlNodeOf(m)
+		if err != nil {
+			return nil, err
+		}
+		data = n
+	}
+
 	bb, err := yaml.Marshal(data)
 	if err != nil {
 		return nil, err
@@ -62,18 +76,688 @@ func SaveYaml(data interface{}) ([]byte, error) {
 	return bb, nil
 }
 
-// Converte in lowercase tutti i nomi variabili delle strutture,
-// favorendo un comportamento case insensitive in fase di unmarshal.
-func lowerKeys(in []byte) []byte {
-	lines := []string{}
+type yamlVisit struct {
+	node *yaml.Node
+	t    reflect.Type
+}
+
+var yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// Riscrive le chiavi dei mapping corrispondenti ai campi delle struct del tipo t
+// nel nome atteso dal decoder, senza distinzione tra maiuscole e minuscole.
+// Come il decoder in modalità strict, ritorna errore per le chiavi non previste.
+//   - visited: nodi già elaborati, per gli alias.
+func yamlMatchKeys(n *yaml.Node, t reflect.Type, visited map[yamlVisit]bool) error {
+	if n == nil || t == nil {
+		return nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(yamlUnmarshalerType) {
+		return nil // decodifica personalizzata
+	}
+
+	if visited[yamlVisit{n, t}] {
+		return nil
+	}
+	visited[yamlVisit{n, t}] = true
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.Content {
+			err := yamlMatchKeys(child, t, visited)
+			if err != nil {
+				return err
+			}
+		}
+
+	case yaml.AliasNode:
+		return yamlMatchKeys(n.Alias, t, visited)
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for _, child := range n.Content {
+			err := yamlMatchKeys(child, t.Elem(), visited)
+			if err != nil {
+				return err
+			}
+		}
+
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Map:
+			for i := 1; i < len(n.Content); i += 2 {
+				err := yamlMatchKeys(n.Content[i], t.Elem(), visited)
+				if err != nil {
+					return err
+				}
+			}
+
+		case reflect.Struct:
+			fields := yamlFields(t)
+
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+
+				// Merge key: i mapping referenziati appartengono alla stessa struct.
+				if key.Tag == "!!merge" || key.Value == "<<" && key.Style == 0 {
+					err := yamlMergeKeys(value, t, visited)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+
+				field, ok := fields[strings.ToLower(key.Value)]
+				if !ok {
+					return &yaml.TypeError{Errors: []string{
+						fmt.Sprintf("line %d: field %s not found in type %s", key.Line, key.Value, t),
+					}}
+				}
+
+				key.Value = field.key
+
+				err := yamlMatchKeys(value, field.t, visited)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Elabora il valore di una merge key: un mapping, un alias o una sequenza di questi.
+func yamlMergeKeys(n *yaml.Node, t reflect.Type, visited map[yamlVisit]bool) error {
+	if n.Kind != yaml.SequenceNode {
+		return yamlMatchKeys(n, t, visited)
+	}
+
+	for _, child := range n.Content {
+		err := yamlMatchKeys(child, t, visited)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type yamlField struct {
+	key string // Nome atteso dal decoder.
+	t   reflect.Type
+}
+
+// Ritorna i campi della struct decodificabili, per nome in minuscolo,
+// secondo le regole del decoder Yaml (nome del campo in minuscolo, tag `yaml`, campi inline).
+func yamlFields(t reflect.Type) map[string]yamlField {
+	fields := make(map[string]yamlField)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		if strings.Contains(","+flags+",", ",inline,") {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, f := range yamlFields(ft) {
+					fields[k] = f
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[strings.ToLower(name)] = yamlField{key: name, t: field.Type}
+	}
+
+	return fields
+}
+
+// Imposta il valore al percorso indicato nel documento Yaml,
+// mantenendone commenti, ordine e formattazione (vedi edit.go).
+// Il valore viene scritto in stile flow (come Json) all'interno dei nodi flow, a blocchi altrimenti;
+// gli alias non possono essere modificati.
+func SetYaml(bb []byte, path string, value interface{}) ([]byte, error) {
+	segments, err := splitKeyPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := orderedData(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(bb, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &yamlEditor{bb: bb, unit: detectIndent(bb, "  ")}
+	if strings.Contains(e.unit, "\t") {
+		e.unit = "  "
+	}
+
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		// Documento vuoto.
+		nested, err := nestedValue(segments, v)
+		if err != nil {
+			return nil, err
+		}
+		text, err := e.renderBlock(nested, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(bb)) > 0 && !bytes.HasSuffix(bb, []byte("\n")) {
+			text = "\n" + text
+		}
+		return append(bb, text+"\n"...), nil
+	}
+
+	n := doc.Content[0]
+	var key *yaml.Node    // Chiave del valore n, se di un mapping.
+	var parent *yaml.Node // Nodo contenente n.
+
+	for i, segment := range segments {
+		if n.Kind == yaml.AliasNode {
+			return nil, fmt.Errorf("cannot edit alias at %s", joinSegments(segments[:i]))
+		}
+
+		found := -1
+		switch {
+		case segment.index < 0 && n.Kind == yaml.MappingNode:
+			for k := 0; k+1 < len(n.Content); k += 2 {
+				if strings.EqualFold(n.Content[k].Value, segment.key) {
+					found = k + 1
+					break
+				}
+			}
+		case segment.index >= 0 && n.Kind == yaml.SequenceNode:
+			if segment.index < len(n.Content) {
+				found = segment.index
+			} else if segment.index > len(n.Content) {
+				return nil, fmt.Errorf("index out of range at %s", joinSegments(segments[:i+1]))
+			}
+		case segment.index < 0:
+			return nil, fmt.Errorf("%s is not a mapping", joinSegments(segments[:i]))
+		default:
+			return nil, fmt.Errorf("%s is not a sequence", joinSegments(segments[:i]))
+		}
+
+		if found < 0 {
+			nested, err := nestedValue(segments[i+1:], v)
+			if err != nil {
+				return nil, err
+			}
+			return e.insert(n, segment, nested)
+		}
 
-	for _, line := range strings.Split(string(in), "\n") {
-		if strings.Contains(line, ":") {
-			parts := strings.SplitN(line, ":", 2)
-			line = fmt.Sprintf("%s:%s", strings.ToLower(parts[0]), parts[1])
+		parent = n
+		key = nil
+		if n.Kind == yaml.MappingNode {
+			key = n.Content[found-1]
 		}
-		lines = append(lines, line)
+		n = n.Content[found]
+	}
+
+	if n.Kind == yaml.AliasNode {
+		return nil, fmt.Errorf("cannot edit alias at %s", path)
+	}
+
+	return e.replace(parent, key, n, v)
+}
+
+// Modifica del testo di un documento Yaml, sulla base delle posizioni dei nodi.
+type yamlEditor struct {
+	bb   []byte
+	unit string // Unità di indentazione.
+}
+
+// Sostituisce il valore n, figlio di parent (con chiave key se parent è un mapping).
+func (e *yamlEditor) replace(parent, key, n *yaml.Node, v interface{}) ([]byte, error) {
+	start := e.offset(n)
+
+	if parent.Style&yaml.FlowStyle != 0 {
+		end, err := e.nodeEnd(n, start, true, 0)
+		if err != nil {
+			return nil, err
+		}
+		text, err := renderJsonInline(v)
+		if err != nil {
+			return nil, err
+		}
+		return splice(e.bb, start, end, text), nil
+	}
+
+	// Posizione successiva all'intestazione del valore ("chiave:" o "-") e relativa indentazione.
+	header, base, err := e.header(parent, key, n)
+	if err != nil {
+		return nil, err
+	}
+
+	end := header
+	if !yamlEmpty(n) {
+		end, err = e.nodeEnd(n, start, false, base)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	text, inline, err := e.render(v, key != nil, base)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := e.bb[header:lineEnd(e.bb, header)]
+	if trimmed := bytes.TrimSpace(rest); len(trimmed) > 0 && trimmed[0] == '#' {
+		// Commento sulla riga dell'intestazione: viene mantenuto.
+		at := lineEnd(e.bb, header)
+		if inline {
+			return splice(splice(e.bb, at, end, ""), header, header, " "+text), nil
+		}
+		return splice(e.bb, at, end, text), nil
+	}
+
+	if inline {
+		return splice(e.bb, header, end, " "+text), nil
+	}
+	if key == nil {
+		return splice(e.bb, header, end, text), nil
 	}
 
-	return []byte(strings.Join(lines, "\n"))
+	// Il valore a blocchi segue eventuali commenti sulla riga dell'intestazione.
+	bb := splice(e.bb, header, end, "")
+	at := lineEnd(bb, header)
+	return splice(bb, at, at, text), nil
+}
+
+// Inserisce la chiave o l'elemento in coda al mapping o alla sequenza n.
+func (e *yamlEditor) insert(n *yaml.Node, segment pathSegment, v interface{}) ([]byte, error) {
+	if n.Style&yaml.FlowStyle != 0 {
+		text, err := renderJsonInline(v)
+		if err != nil {
+			return nil, err
+		}
+		if segment.index < 0 {
+			text = yamlInlineKey(segment.key) + ": " + text
+		}
+
+		start := e.offset(n)
+		end, err := e.nodeEnd(n, start, true, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(n.Content) == 0 {
+			return splice(e.bb, start+1, end-1, text), nil
+		}
+
+		last := n.Content[len(n.Content)-1]
+		at, err := e.nodeEnd(last, e.offset(last), true, 0)
+		if err != nil {
+			return nil, err
+		}
+		return splice(e.bb, at, at, ", "+text), nil
+	}
+
+	// Fine dell'ultimo valore, comprensiva di eventuali commenti sulla stessa riga.
+	last := n.Content[len(n.Content)-1]
+	var lastParent, lastKey *yaml.Node = n, nil
+	if n.Kind == yaml.MappingNode {
+		lastKey = n.Content[len(n.Content)-2]
+	}
+	header, base, err := e.header(lastParent, lastKey, last)
+	if err != nil {
+		return nil, err
+	}
+	end := header
+	if !yamlEmpty(last) {
+		end, err = e.nodeEnd(last, e.offset(last), false, base)
+		if err != nil {
+			return nil, err
+		}
+	}
+	at := lineEnd(e.bb, end)
+
+	indent := strings.Repeat(" ", base)
+	if segment.index >= 0 {
+		text, inline, err := e.render(v, false, base)
+		if err != nil {
+			return nil, err
+		}
+		if inline {
+			text = " " + text
+		}
+		return splice(e.bb, at, at, "\n"+indent+"-"+text), nil
+	}
+
+	text, inline, err := e.render(v, true, base)
+	if err != nil {
+		return nil, err
+	}
+	if inline {
+		text = " " + text
+	}
+	return splice(e.bb, at, at, "\n"+indent+yamlInlineKey(segment.key)+":"+text), nil
+}
+
+// Ritorna la posizione successiva all'intestazione del valore n a blocchi ("chiave:" o "-")
+// e l'indentazione della chiave o del trattino.
+func (e *yamlEditor) header(parent, key, n *yaml.Node) (int, int, error) {
+	if key != nil {
+		start := e.offset(key)
+		end, err := e.nodeEnd(key, start, false, -1)
+		if err != nil {
+			return 0, 0, err
+		}
+		for end < len(e.bb) && e.bb[end] != ':' {
+			if e.bb[end] != ' ' && e.bb[end] != '\t' {
+				return 0, 0, fmt.Errorf("line %d: unsupported key", key.Line)
+			}
+			end++
+		}
+		if end >= len(e.bb) {
+			return 0, 0, fmt.Errorf("line %d: unsupported key", key.Line)
+		}
+		return end + 1, key.Column - 1, nil
+	}
+
+	// Elemento di sequenza: il trattino precede il valore.
+	i := e.offset(n) - 1
+	if yamlEmpty(n) {
+		i = e.offset(n)
+		for i < len(e.bb) && e.bb[i] != '-' {
+			i++
+		}
+	}
+	for i >= 0 && (e.bb[i] == ' ' || e.bb[i] == '\t' || e.bb[i] == '\n' || e.bb[i] == '\r') {
+		i--
+	}
+	if i < 0 || e.bb[i] != '-' {
+		return 0, 0, fmt.Errorf("line %d: unsupported sequence item", n.Line)
+	}
+	return i + 1, parent.Column - 1, nil
+}
+
+// Ritorna la posizione successiva al valore n, che inizia in start.
+//   - flow: valore all'interno di un nodo flow;
+//   - base: indentazione dell'intestazione del valore a blocchi, -1 per le chiavi.
+func (e *yamlEditor) nodeEnd(n *yaml.Node, start int, flow bool, base int) (int, error) {
+	bb := e.bb
+	end := start
+
+	switch {
+	case n.Kind == yaml.ScalarNode && (n.Style&(yaml.LiteralStyle|yaml.FoldedStyle)) != 0:
+		end = lineEnd(bb, start)
+
+	case n.Style&yaml.FlowStyle != 0 && (n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode):
+		return yamlFlowEnd(bb, start, n.Line)
+
+	case n.Kind == yaml.ScalarNode && bb[start] == '"':
+		for end = start + 1; end < len(bb) && bb[end] != '"'; end++ {
+			if bb[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(bb) {
+			return 0, fmt.Errorf("line %d: unterminated string", n.Line)
+		}
+		return end + 1, nil
+
+	case n.Kind == yaml.ScalarNode && bb[start] == '\'':
+		for end = start + 1; end < len(bb); end++ {
+			if bb[end] == '\'' {
+				if end+1 < len(bb) && bb[end+1] == '\'' {
+					end++
+					continue
+				}
+				break
+			}
+		}
+		if end >= len(bb) {
+			return 0, fmt.Errorf("line %d: unterminated string", n.Line)
+		}
+		return end + 1, nil
+
+	case n.Kind == yaml.ScalarNode:
+		// Scalare semplice: fino a fine riga o al commento (o agli indicatori flow).
+		stop := lineEnd(bb, start)
+		for end < stop {
+			c := bb[end]
+			if c == '#' && end > start && (bb[end-1] == ' ' || bb[end-1] == '\t') ||
+				flow && (c == ',' || c == ']' || c == '}') ||
+				base < 0 && c == ':' && (end+1 == stop || bb[end+1] == ' ' || bb[end+1] == '\t') {
+				break
+			}
+			end++
+		}
+		for end > start && (bb[end-1] == ' ' || bb[end-1] == '\t') {
+			end--
+		}
+		if flow || base < 0 {
+			return end, nil
+		}
+
+	default:
+		end = lineEnd(bb, start)
+	}
+
+	// Righe successive più indentate dell'intestazione (o sequenze compatte alla stessa indentazione).
+	compact := n.Kind == yaml.SequenceNode
+	at := lineEnd(bb, start)
+	for at < len(bb) {
+		next := bytes.IndexByte(bb[at:], '\n')
+		if next < 0 {
+			break
+		}
+		row := bb[at+next+1 : lineEnd(bb, at+next+1)]
+		at = at + next + 1
+
+		trimmed := bytes.TrimLeft(row, " ")
+		if len(bytes.TrimSpace(trimmed)) == 0 {
+			continue
+		}
+		indent := len(row) - len(trimmed)
+		if indent > base || compact && indent == base && trimmed[0] == '-' {
+			if n.Kind == yaml.ScalarNode && trimmed[0] == '#' {
+				break
+			}
+			end = at + len(row)
+			continue
+		}
+		break
+	}
+
+	return end, nil
+}
+
+// Ritorna la posizione successiva al nodo flow che inizia in start.
+func yamlFlowEnd(bb []byte, start, line int) (int, error) {
+	depth := 0
+	for i := start; i < len(bb); i++ {
+		switch c := bb[i]; c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		case '"', '\'':
+			for i++; i < len(bb) && bb[i] != c; i++ {
+				if c == '"' && bb[i] == '\\' {
+					i++
+				}
+			}
+		case '#':
+			if i > start && (bb[i-1] == ' ' || bb[i-1] == '\t' || bb[i-1] == '\n') {
+				i = lineEnd(bb, i)
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("line %d: unterminated flow collection", line)
+}
+
+// Ritorna la posizione nel documento dell'inizio del nodo.
+func (e *yamlEditor) offset(n *yaml.Node) int {
+	pos := 0
+	for line := 1; line < n.Line; line++ {
+		i := bytes.IndexByte(e.bb[pos:], '\n')
+		if i < 0 {
+			return len(e.bb)
+		}
+		pos += i + 1
+	}
+
+	// Le colonne sono espresse in caratteri.
+	for col := 1; col < n.Column && pos < len(e.bb); col++ {
+		_, size := utf8.DecodeRune(e.bb[pos:])
+		pos += size
+	}
+
+	return pos
+}
+
+// Codifica il valore da scrivere dopo l'intestazione "chiave:" (isValue) o "-" con indentazione base:
+// gli scalari e le collezioni vuote su una riga (inline), le altre collezioni a blocchi su nuove righe.
+func (e *yamlEditor) render(v interface{}, isValue bool, base int) (string, bool, error) {
+	switch t := v.(type) {
+	case *ordered.OrderedMap:
+		if emptyMap(t) {
+			return "{}", true, nil
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			return "[]", true, nil
+		}
+	default:
+		text, err := yamlScalar(v)
+		return text, true, err
+	}
+
+	if isValue {
+		text, err := e.renderBlock(v, strings.Repeat(" ", base)+e.unit)
+		return "\n" + text, false, err
+	}
+
+	// Elemento di sequenza: la prima riga segue il trattino.
+	text, err := e.renderBlock(v, strings.Repeat(" ", base+2))
+	return " " + strings.TrimLeft(text, " "), false, err
+}
+
+// Codifica il valore a blocchi, con l'indentazione indicata su ogni riga.
+func (e *yamlEditor) renderBlock(v interface{}, indent string) (string, error) {
+	n, err := yamlNodeOf(v)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(len(e.unit))
+	err = enc.Encode(n)
+	if err != nil {
+		return "", err
+	}
+
+	rows := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for i := range rows {
+		rows[i] = indent + rows[i]
+	}
+
+	return strings.Join(rows, "\n"), nil
+}
+
+// Converte il valore (vedi orderedData) in un nodo Yaml, mantenendo l'ordine delle chiavi.
+func yamlNodeOf(v interface{}) (*yaml.Node, error) {
+	n := &yaml.Node{}
+
+	switch t := v.(type) {
+	case *ordered.OrderedMap:
+		n.Kind = yaml.MappingNode
+		iter := t.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+			key := &yaml.Node{}
+			err := key.Encode(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := yamlNodeOf(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, key, value)
+		}
+
+	case []interface{}:
+		n.Kind = yaml.SequenceNode
+		for _, item := range t {
+			child, err := yamlNodeOf(item)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, child)
+		}
+
+	default:
+		err := n.Encode(scalarValue(v))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// Codifica lo scalare su una riga; le stringhe su più righe sono scritte tra doppi apici.
+func yamlScalar(v interface{}) (string, error) {
+	bb, err := yaml.Marshal(scalarValue(v))
+	if err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSuffix(string(bb), "\n")
+	if strings.Contains(text, "\n") {
+		return renderJson(v, "", "")
+	}
+
+	return text, nil
+}
+
+// Ritorna la chiave scritta come scalare Yaml su una riga.
+func yamlInlineKey(key string) string {
+	text, err := yamlScalar(key)
+	if err != nil {
+		return strconv.Quote(key)
+	}
+	return text
+}
+
+// Indica se il nodo è un valore nullo implicito (chiave senza valore).
+func yamlEmpty(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null" && n.Value == "" && n.Style == 0
 }
@@ -0,0 +1,305 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Origine del valore di un campo di configurazione.
+type Origin struct {
+	Source string // Descrizione della sorgente, vedi Source.String().
+	File   string // File da cui proviene il valore, se la sorgente è un file.
+	Line   int    // Riga nel file (a partire da 1), 0 se non disponibile.
+}
+
+func (o Origin) String() string {
+	if o.File == "" {
+		return o.Source
+	}
+	if o.Line == 0 {
+		return o.File
+	}
+	return fmt.Sprintf("%s:%d", o.File, o.Line)
+}
+
+// Traccia dell'origine dei valori di una configurazione caricata a più livelli.
+// I percorsi dei campi hanno i livelli separati da '.' e gli indici degli array tra '[]',
+// ad es. "Main.ParamInt", "Users[1].Name"; sono case insensitive.
+type Provenance struct {
+	fields  []flatField       // Campi foglia, nell'ordine della struttura.
+	origins map[string]Origin // Origine per percorso in minuscolo.
+}
+
+// Campo foglia della configurazione.
+type flatField struct {
+	path  string
+	value string // Valore in formato Json.
+}
+
+// Crea una traccia attribuendo tutti i valori attuali di cfg a source.
+func newProvenance(cfg interface{}, source string) (*Provenance, error) {
+	fields, err := flattenData(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provenance{
+		fields:  fields,
+		origins: make(map[string]Origin),
+	}
+	for _, f := range fields {
+		p.origins[strings.ToLower(f.path)] = Origin{Source: source}
+	}
+
+	return p, nil
+}
+
+// Ritorna l'origine del valore del campo indicato.
+func (p *Provenance) Origin(path string) (Origin, bool) {
+	o, ok := p.origins[strings.ToLower(path)]
+	return o, ok
+}
+
+// Ritorna l'elenco dei campi con il valore effettivo e la relativa origine, uno per riga.
+func (p *Provenance) Explain() string {
+	var builder strings.Builder
+
+	for _, f := range p.fields {
+		o := p.origins[strings.ToLower(f.path)]
+		builder.WriteString(fmt.Sprintf("%s = %s (%s)\n", f.path, f.value, o))
+	}
+
+	return builder.String()
+}
+
+// Aggiorna la traccia dopo l'applicazione di una sorgente.
+// Vengono attribuiti alla sorgente i campi definiti nei suoi file, quelli da essa impostati
+// e i campi il cui valore è cambiato.
+//   - files: file caricati dalla sorgente in ordine di applicazione (vedi MultiFileSource);
+//     se vuoto, origin stesso se è un file.
+//   - keys: percorsi dei campi impostati dalla sorgente (vedi keysSource), compresi i loro sotto-campi.
+func (p *Provenance) update(cfg interface{}, source Source, origin string, files, keys []string) error {
+	fields, err := flattenData(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 && filepath.IsAbs(origin) && fileExists(origin) {
+		files = []string{origin}
+	}
+
+	// Righe delle chiavi definite in ciascun file, se disponibili.
+	lines := make([]map[string]int, len(files))
+	for i, filename := range files {
+		lines[i] = fileKeyLines(filename)
+	}
+
+	o := Origin{Source: source.String()}
+	if len(files) == 1 {
+		o.File = files[0]
+	}
+
+	prev := make(map[string]string, len(p.fields))
+	for _, f := range p.fields {
+		prev[f.path] = f.value
+	}
+
+	origins := make(map[string]Origin, len(fields))
+	for _, f := range fields {
+		key := strings.ToLower(f.path)
+
+		// Il valore proviene dall'ultimo file che definisce la chiave.
+		if i, line := lastKeyLine(lines, key, false); line > 0 {
+			origins[key] = Origin{Source: o.Source, File: files[i], Line: line}
+			continue
+		}
+
+		if hasKeyPrefix(keys, key) {
+			origins[key] = o
+			continue
+		}
+
+		if v, ok := prev[f.path]; ok && v == f.value {
+			origins[key] = p.origins[key]
+			continue
+		}
+
+		// Valore cambiato: la riga è quella del livello più vicino presente nei file.
+		if i, line := lastKeyLine(lines, key, true); line > 0 {
+			origins[key] = Origin{Source: o.Source, File: files[i], Line: line}
+		} else {
+			origins[key] = o
+		}
+	}
+
+	p.fields = fields
+	p.origins = origins
+
+	return nil
+}
+
+// Ritorna true se path (in minuscolo) è uno dei percorsi indicati o un loro sotto-campo.
+func hasKeyPrefix(keys []string, path string) bool {
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		if path == key || strings.HasPrefix(path, key+".") || strings.HasPrefix(path, key+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ritorna l'indice dell'ultimo file che definisce la chiave e la relativa riga, 0 se assente.
+//   - ancestor: true per cercare anche il livello più vicino presente (vedi ancestorLine).
+func lastKeyLine(lines []map[string]int, key string, ancestor bool) (int, int) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i][key]
+		if ancestor {
+			line = ancestorLine(lines[i], key)
+		}
+		if line > 0 {
+			return i, line
+		}
+	}
+
+	return 0, 0
+}
+
+// Aggiorna i valori dei campi mantenendone l'origine, ad es. dopo l'interpolazione (vedi Interpolate).
+func (p *Provenance) refresh(cfg interface{}) error {
+	fields, err := flattenData(cfg)
+	if err != nil {
+		return err
+	}
+
+	p.fields = fields
+
+	return nil
+}
+
+// Ritorna la riga del livello più profondo di path presente in lines, 0 se assente.
+func ancestorLine(lines map[string]int, path string) int {
+	for path != "" {
+		if line, ok := lines[path]; ok {
+			return line
+		}
+
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+
+	return 0
+}
+
+// Ritorna le righe delle chiavi definite nel file, nil se non disponibili.
+func fileKeyLines(filename string) map[string]int {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	ext := filepath.Ext(filename)
+	if !isKnownExt(ext) {
+		ext = parsers.DetectFormat(bb)
+	}
+
+	return keyLines(ext, bb)
+}
+
+// Ritorna le righe delle chiavi definite nel documento del formato indicato, nil se non disponibili.
+func keyLines(ext string, bb []byte) map[string]int {
+	var lines map[string]int
+	var err error
+
+	switch ext {
+	case ".json", ".jsonc":
+		lines, err = parsers.JsoncKeyLines(bb)
+	case ".json5":
+		lines, err = parsers.Json5KeyLines(bb)
+	case ".yaml":
+		lines, err = parsers.YamlKeyLines(bb)
+	case ".toml":
+		lines, err = parsers.TomlKeyLines(bb)
+	case ".ini", ".conf":
+		lines, err = parsers.IniKeyLines(bb)
+	case ".env":
+		lines, err = parsers.DotenvKeyLines(bb)
+	case ".hcl":
+		lines, err = parsers.HclKeyLines(bb)
+	case ".properties":
+		lines, err = parsers.PropertiesKeyLines(bb)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	return lines
+}
+
+// Appiattisce la configurazione nell'elenco ordinato dei suoi campi foglia.
+func flattenData(cfg interface{}) ([]flatField, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m := ordered.NewOrderedMap()
+
+	err = json.Unmarshal(b, m)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []flatField
+	flattenValue("", m, &fields)
+
+	return fields, nil
+}
+
+func flattenValue(path string, v interface{}, fields *[]flatField) {
+	switch vv := v.(type) {
+	case *ordered.OrderedMap:
+		iter := vv.EntriesIter()
+		empty := true
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+			empty = false
+
+			key := pair.Key
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenValue(key, pair.Value, fields)
+		}
+		if empty && path != "" {
+			*fields = append(*fields, flatField{path: path, value: "{}"})
+		}
+
+	case []interface{}:
+		for i, elt := range vv {
+			flattenValue(path+"["+strconv.Itoa(i)+"]", elt, fields)
+		}
+		if len(vv) == 0 {
+			*fields = append(*fields, flatField{path: path, value: "[]"})
+		}
+
+	default:
+		b, _ := json.Marshal(vv)
+		*fields = append(*fields, flatField{path: path, value: string(b)})
+	}
+}
@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	dir := t.TempDir()
+
+	yaml := `
+main:
+  paramint: 12
+users:
+  - name: ${PROVTEST_USER}
+`
+	err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte(yaml), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonc := `{
+	// Override
+	"Main": {
+		"ParamString": "file"
+	}
+}`
+	err = os.WriteFile(filepath.Join(dir, "override.jsonc"), []byte(jsonc), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PROVTEST_MAIN_PARAMFLOAT", "2.5")
+	t.Setenv("PROVTEST_USER", "Foo")
+	t.Setenv("PROVTEST_MAIN_PARAMBOOL", "true") // stesso valore del default
+
+	var data MySettings
+
+	loader := NewLoader(
+		DefaultsSource(defaultSettings()),
+		FileSource(filepath.Join(dir, "base.yaml"), true),
+		FileSource(filepath.Join(dir, "override.jsonc"), true),
+		EnvSource("provtest"),
+	)
+
+	_, err = loader.Load(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"Main.ParamInt", filepath.Join(dir, "base.yaml") + ":3"},
+		{"main.paramstring", filepath.Join(dir, "override.jsonc") + ":4"},
+		{"Main.ParamFloat", "env provtest"},
+		{"Main.ParamBool", "env provtest"},
+		{"Users[0].Name", filepath.Join(dir, "base.yaml") + ":5"},
+	}
+
+	for _, test := range tests {
+		o, ok := loader.Origin(test.path)
+		if !ok {
+			t.Fatalf("%s: origin not found", test.path)
+		}
+		if o.String() != test.want {
+			t.Fatalf("%s: got %s, want %s", test.path, o, test.want)
+		}
+	}
+
+	if _, ok := loader.Origin("Users[1].Name"); ok {
+		t.Fatal("unexpected origin for removed item")
+	}
+
+	explain := loader.Explain()
+	if !strings.Contains(explain, "Main.ParamInt = 12 ("+filepath.Join(dir, "base.yaml")+":3)\n") ||
+		!strings.Contains(explain, `Users[0].Name = "Foo" (`+filepath.Join(dir, "base.yaml")+":5)\n") {
+		t.Fatal("explain mismatch:\n" + explain)
+	}
+}
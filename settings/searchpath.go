@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Ritorna i file candidati (privi di estensione) per la configurazione dell'applicazione,
+// in ordine di precedenza crescente:
+//   - $XDG_CONFIG_DIRS/<app>/<app>, default /etc/xdg (la prima directory dell'elenco ha la precedenza);
+//   - /etc/<app>/<app>;
+//   - $XDG_CONFIG_HOME/<app>/<app>, default ~/.config;
+//   - <app> nella directory corrente.
+func SearchPaths(appName string) []string {
+	var paths []string
+
+	xdgDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if xdgDirs == "" {
+		xdgDirs = "/etc/xdg"
+	}
+	dirs := filepath.SplitList(xdgDirs)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if filepath.IsAbs(dirs[i]) {
+			paths = append(paths, filepath.Join(dirs[i], appName, appName))
+		}
+	}
+
+	paths = append(paths, filepath.Join("/etc", appName, appName))
+
+	xdgHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgHome == "" || !filepath.IsAbs(xdgHome) {
+		if homeDir, err := homedir.Dir(); err == nil {
+			xdgHome = filepath.Join(homeDir, ".config")
+		}
+	}
+	if xdgHome != "" {
+		paths = append(paths, filepath.Join(xdgHome, appName, appName))
+	}
+
+	if currDir, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(currDir, appName))
+	}
+
+	return removeDuplicates(paths)
+}
+
+// Carica la configurazione da tutti i percorsi standard (vedi SearchPaths),
+// ciascuno a mo' di override dei precedenti; per ogni percorso tenta il caricamento
+// di qualsiasi formato conosciuto.
+//   - appName: nome dell'applicazione.
+//   - cfg: PUNTATORE a struttura configurazione da popolare.
+//
+// Ritorna l'elenco dei file caricati, eventualmente vuoto.
+func LoadFromSearchPath(appName string, cfg interface{}) (loadedFilenames []string, err error) {
+	loadedFilenames, _, err = loadFromSearchPath(appName, cfg)
+	return
+}
+
+// Come LoadFromSearchPath, ritornando anche tutti i file caricati, compresi gli inclusi, in ordine di applicazione.
+func loadFromSearchPath(appName string, cfg interface{}) (loadedFilenames, files []string, err error) {
+	for _, path := range SearchPaths(appName) {
+		loadedFilename, included, err := loadFile(path, cfg, false, false)
+		if err != nil {
+			return loadedFilenames, files, err
+		}
+		if loadedFilename != "" {
+			loadedFilenames = append(loadedFilenames, loadedFilename)
+			files = append(files, included...)
+			files = append(files, loadedFilename)
+		}
+	}
+
+	return loadedFilenames, files, nil
+}
+
+type searchPathSource struct {
+	appName string
+}
+
+// Sorgente dai percorsi standard, vedi LoadFromSearchPath.
+func SearchPathSource(appName string) Source {
+	return &searchPathSource{appName: appName}
+}
+
+func (s *searchPathSource) String() string {
+	return "search path " + s.appName
+}
+
+func (s *searchPathSource) Load(cfg interface{}) (string, error) {
+	origin, _, _, err := s.LoadFiles(cfg)
+	return origin, err
+}
+
+func (s *searchPathSource) LoadFiles(cfg interface{}) (string, []string, []string, error) {
+	loadedFilenames, files, err := loadFromSearchPath(s.appName, cfg)
+	if err != nil || len(loadedFilenames) == 0 {
+		return "", nil, nil, err
+	}
+
+	return strings.Join(loadedFilenames, ", "), files, nil, nil
+}
+
+func removeDuplicates(paths []string) []string {
+	out := make([]string, 0, len(paths))
+
+	for i, path := range paths {
+		dup := false
+		for _, next := range paths[i+1:] {
+			if next == path {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, path)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,66 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFromSearchPath(t *testing.T) {
+	const app = "mu-config-searchpath-test"
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"xdg1/" + app + "/" + app + ".yaml":  "main:\n  paramstring: xdg1\n  paramint: 1\n",
+		"xdg2/" + app + "/" + app + ".toml":  "[main]\nparamstring = 'xdg2'\nparamint = 2\nparamfloat = 2.0\n",
+		"home/" + app + "/" + app + ".jsonc": `{ "main": { "paramint": 3 } }`,
+		"cwd/" + app + ".yaml":               "main:\n  parambool: false\n",
+	})
+
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(root, "xdg1")+string(filepath.ListSeparator)+filepath.Join(root, "xdg2"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "home"))
+
+	currDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(filepath.Join(root, "cwd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(currDir)
+
+	data := defaultSettings()
+
+	loaded, err := LoadFromSearchPath(app, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) != 4 || loaded[0] != filepath.Join(root, "xdg2", app, app+".toml") {
+		t.Fatalf("unexpected loaded files %v", loaded)
+	}
+
+	if data.Main.ParamString != "xdg1" || data.Main.ParamInt != 3 || data.Main.ParamFloat != 2.0 || data.Main.ParamBool {
+		t.Fatalf("precedenza errata: %+v", data.Main)
+	}
+
+	// Tramite Loader: i file sono riportati singolarmente, con file e riga di ciascun valore.
+	loader := NewLoader(DefaultsSource(defaultSettings()), SearchPathSource(app))
+	results, err := loader.Load(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[1].Files) != 4 || results[1].Files[1] != filepath.Join(root, "xdg1", app, app+".yaml") {
+		t.Fatalf("unexpected files %v", results[1].Files)
+	}
+
+	o, _ := loader.Origin("Main.ParamString")
+	if o.String() != filepath.Join(root, "xdg1", app, app+".yaml")+":2" {
+		t.Fatalf("origin mismatch: %s", o)
+	}
+	o, _ = loader.Origin("Main.ParamFloat")
+	if o.String() != filepath.Join(root, "xdg2", app, app+".toml")+":4" {
+		t.Fatalf("origin mismatch: %s", o)
+	}
+}
@@ -0,0 +1,290 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Risolutore di riferimenti a secret, nella forma "schema:riferimento".
+type SecretResolver interface {
+	// Ritorna il valore del secret; ref è il riferimento privo dello schema.
+	Resolve(ref string) (string, error)
+}
+
+// Adattatore per l'utilizzo di funzioni come SecretResolver.
+type SecretResolverFunc func(ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// Secret risolto in un campo della configurazione.
+// Del valore viene mantenuto il solo hash, per non conservarlo in memoria in chiaro.
+type resolvedSecret struct {
+	ref  string   // Riferimento originale, ad es. "env:DB_PASS".
+	hash [32]byte // SHA-256 del valore risolto.
+}
+
+// Secret risolti in una istanza di configurazione.
+type resolvedSecrets struct {
+	typ    reflect.Type              // Tipo della struttura configurazione.
+	fields map[string]resolvedSecret // Per percorso del campo.
+}
+
+var secrets = struct {
+	sync.RWMutex
+	resolvers map[string]SecretResolver
+	resolved  map[uintptr]resolvedSecrets // Per indirizzo dell'istanza di configurazione.
+}{
+	resolvers: map[string]SecretResolver{
+		"file": SecretResolverFunc(resolveFileSecret),
+		"env":  SecretResolverFunc(resolveEnvSecret),
+	},
+	resolved: make(map[uintptr]resolvedSecrets),
+}
+
+// Risolutore per i riferimenti "exec:comando arg1 arg2": output del comando, privo dell'eventuale newline finale;
+// gli argomenti sono separati da spazi, senza interpretazione di apici.
+// Non è registrato di default, poiché esegue comandi presenti nella configurazione:
+// va abilitato esplicitamente con RegisterSecretResolver("exec", settings.ExecSecretResolver).
+var ExecSecretResolver SecretResolver = SecretResolverFunc(resolveExecSecret)
+
+// Registra un risolutore per lo schema indicato (es. "vault"), sostituendo l'eventuale esistente.
+// Sono predefiniti:
+//   - file:/percorso: contenuto del file, privo dell'eventuale newline finale;
+//   - env:NOME: valore della variabile d'ambiente.
+//
+// Con resolver nil lo schema viene rimosso.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	if resolver == nil {
+		delete(secrets.resolvers, scheme)
+		return
+	}
+	secrets.resolvers[scheme] = resolver
+}
+
+// Sostituisce i valori stringa dei soli campi con tag `secret:"true"` (comprese slice e map di stringhe)
+// che iniziano per uno schema registrato (vedi RegisterSecretResolver) con il valore del secret referenziato;
+// gli altri valori non vengono mai interpretati come riferimenti.
+//   - cfg: PUNTATORE a struttura configurazione.
+//
+// I campi risolti vengono registrati per istanza di configurazione (l'indirizzo di cfg) e percorso,
+// sostituendo quelli di una precedente invocazione sulla stessa istanza: SaveFile e MarshalDiff
+// vi scrivono il riferimento originale finché il campo contiene il valore risolto.
+func ResolveSecrets(cfg interface{}) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("config data must be a pointer")
+	}
+
+	resolved := make(map[string]resolvedSecret)
+
+	err := walkSecrets(v.Elem(), "", false, func(path, s string) (string, error) {
+		scheme, ref, ok := strings.Cut(s, ":")
+		if !ok {
+			return s, nil
+		}
+
+		secrets.RLock()
+		resolver := secrets.resolvers[scheme]
+		secrets.RUnlock()
+
+		if resolver == nil {
+			return s, nil
+		}
+
+		value, err := resolver.Resolve(ref)
+		if err != nil {
+			return "", fmt.Errorf("%s: cannot resolve %s secret: %w", path, scheme, err)
+		}
+
+		resolved[path] = resolvedSecret{ref: s, hash: sha256.Sum256([]byte(value))}
+
+		return value, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	if len(resolved) == 0 {
+		delete(secrets.resolved, v.Pointer())
+	} else {
+		secrets.resolved[v.Pointer()] = resolvedSecrets{typ: v.Type().Elem(), fields: resolved}
+	}
+
+	return nil
+}
+
+// Ritorna una copia dei dati in cui i campi con secret risolti (vedi ResolveSecrets)
+// sono sostituiti dal loro riferimento.
+//   - data: PUNTATORE all'istanza di configurazione passata a ResolveSecrets, di cui vengono
+//     considerati i soli secret; se è una struttura (ad es. una copia dell'istanza) vengono considerati
+//     i secret di tutte le istanze dello stesso tipo, poiché non è possibile risalire all'originale.
+func redactSecrets(data interface{}) (interface{}, error) {
+	if data == nil {
+		return data, nil
+	}
+
+	v := reflect.ValueOf(data)
+	resolved := make(map[string][]resolvedSecret)
+
+	secrets.RLock()
+	if v.Kind() == reflect.Pointer {
+		if r, ok := secrets.resolved[v.Pointer()]; ok && r.typ == v.Type().Elem() {
+			for path, secret := range r.fields {
+				resolved[path] = append(resolved[path], secret)
+			}
+		}
+	} else {
+		for _, r := range secrets.resolved {
+			if r.typ != v.Type() {
+				continue
+			}
+			for path, secret := range r.fields {
+				resolved[path] = append(resolved[path], secret)
+			}
+		}
+	}
+	secrets.RUnlock()
+
+	if len(resolved) == 0 {
+		return data, nil
+	}
+
+	clone := reflect.New(v.Type())
+
+	err := cloneData(data, clone.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	err = walkSecrets(clone.Elem(), "", false, func(path, s string) (string, error) {
+		hash := sha256.Sum256([]byte(s))
+		for _, secret := range resolved[path] {
+			if hash == secret.hash {
+				return secret.ref, nil
+			}
+		}
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clone.Elem().Interface(), nil
+}
+
+// Applica f ai valori stringa dei campi con tag `secret:"true"`, sostituendoli con il valore ritornato.
+//   - secret: true se v appartiene a un campo secret.
+func walkSecrets(v reflect.Value, path string, secret bool, f func(path, s string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return walkSecrets(v.Elem(), path, secret, f)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinPath(path, field.Name)
+			}
+			err := walkSecrets(v.Field(i), fieldPath, secret || field.Tag.Get("secret") == "true", f)
+			if err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			err := walkSecrets(v.Index(i), fmt.Sprintf("%s[%d]", path, i), secret, f)
+			if err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			keyPath := joinPath(path, fmt.Sprint(iter.Key().Interface()))
+
+			// I valori delle map non sono indirizzabili: si lavora su una copia.
+			elt := reflect.New(iter.Value().Type()).Elem()
+			elt.Set(iter.Value())
+
+			err := walkSecrets(elt, keyPath, secret, f)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elt)
+		}
+
+	case reflect.String:
+		if !secret || !v.CanSet() {
+			return nil
+		}
+
+		s, err := f(path, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	}
+
+	return nil
+}
+
+func resolveFileSecret(ref string) (string, error) {
+	bb, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return trimNewline(string(bb)), nil
+}
+
+func resolveEnvSecret(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s not set", ref)
+	}
+
+	return value, nil
+}
+
+func resolveExecSecret(ref string) (string, error) {
+	args := strings.Fields(ref)
+	if len(args) == 0 {
+		return "", errors.New("empty command")
+	}
+
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return trimNewline(string(out)), nil
+}
+
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
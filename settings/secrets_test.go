@@ -0,0 +1,152 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type secretSettings struct {
+	Main settingsMain
+	DB   secretDB
+}
+
+type secretDB struct {
+	User     string
+	URL      string
+	Password string   `secret:"true"`
+	Tokens   []string `secret:"true"`
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+
+	secretFile := filepath.Join(dir, "db_password")
+	err := os.WriteFile(secretFile, []byte("s3cr3t\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SECRETS_TEST_PASS", "envpass")
+
+	newData := func() secretSettings {
+		return secretSettings{
+			Main: settingsMain{ParamString: "envpass"}, // stesso valore di un secret
+			DB: secretDB{
+				User:     "env:SECRETS_TEST_PASS",
+				URL:      "file://" + secretFile,
+				Password: "file:" + secretFile,
+				Tokens:   []string{"env:SECRETS_TEST_PASS", "exec:echo execpass", "unknown:scheme"},
+			},
+		}
+	}
+
+	// Lo schema exec non è registrato di default.
+	data := newData()
+	err = ResolveSecrets(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.DB.Tokens[1] != "exec:echo execpass" {
+		t.Fatal("exec resolved without registration")
+	}
+
+	RegisterSecretResolver("exec", ExecSecretResolver)
+	defer RegisterSecretResolver("exec", nil)
+
+	data = newData()
+	err = ResolveSecrets(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.DB.Password != "s3cr3t" || data.DB.Tokens[0] != "envpass" ||
+		data.DB.Tokens[1] != "execpass" || data.DB.Tokens[2] != "unknown:scheme" {
+		t.Fatalf("secrets non risolti: %+v", data)
+	}
+	// I campi privi del tag non vengono interpretati.
+	if data.DB.User != "env:SECRETS_TEST_PASS" || data.DB.URL != "file://"+secretFile {
+		t.Fatalf("campi non secret risolti: %+v", data)
+	}
+
+	filename := filepath.Join(dir, "saved.yaml")
+	err = SaveFile(filename, data, secretSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := string(bb)
+	if strings.Contains(saved, "s3cr3t") || strings.Contains(saved, "- envpass") || strings.Contains(saved, "- execpass") {
+		t.Fatal("secret salvati:\n" + saved)
+	}
+	if !strings.Contains(saved, "Password: file:"+secretFile) || !strings.Contains(saved, "- env:SECRETS_TEST_PASS") ||
+		!strings.Contains(saved, "- exec:echo execpass") {
+		t.Fatal("riferimenti non salvati:\n" + saved)
+	}
+	// Campo non secret con lo stesso valore di un secret: salvato così com'è.
+	if !strings.Contains(saved, "ParamString: envpass") {
+		t.Fatal("campo non secret sostituito:\n" + saved)
+	}
+	if data.DB.Password != "s3cr3t" {
+		t.Fatal("configurazione modificata dal salvataggio")
+	}
+
+	// Un secret modificato dopo la risoluzione viene salvato con il nuovo valore.
+	data.DB.Password = "changed"
+	bb, err = MarshalDiff(data, nil, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bb), "password: changed") {
+		t.Fatal("secret modificato non salvato:\n" + string(bb))
+	}
+
+	data.DB.Password = "env:SECRETS_TEST_UNSET"
+
+	err = ResolveSecrets(&data)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRedactSecretsInstances(t *testing.T) {
+	t.Setenv("SECRETS_TEST_A", "alpha")
+
+	var a, b secretSettings
+	a.DB.Password = "env:SECRETS_TEST_A"
+	b.DB.Password = "alpha" // stesso valore, ma non risolto
+
+	err := ResolveSecrets(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Una seconda istanza dello stesso tipo non sostituisce i secret della prima.
+	err = ResolveSecrets(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []interface{}{&a, a} {
+		bb, err := MarshalDiff(data, nil, "json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(bb), `"Password": "env:SECRETS_TEST_A"`) {
+			t.Fatal("secret salvato:\n" + string(bb))
+		}
+	}
+
+	bb, err := MarshalDiff(&b, nil, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bb), `"Password": "alpha"`) {
+		t.Fatal("valore non risolto sostituito:\n" + string(bb))
+	}
+}
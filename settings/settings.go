@@ -3,7 +3,10 @@ package settings
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -11,96 +14,385 @@ import (
 	"github.com/modulo-srl/mu-config/settings/parsers"
 )
 
+// Estensioni dei formati conosciuti,
+// nell'ordine in cui vengono cercate per i nomi file sprovvisti di estensione.
+var knownExts = []string{".json", ".jsonc", ".json5", ".yaml", ".toml", ".ini", ".conf", ".env", ".hcl", ".properties"}
+
 // Carica la configurazione da file.
 //   - filename: se non ha percorso o lo ha relativo, sarà rispetto alla directory corrente;
 //     se ha percorso assoluto può anche iniziare per '~'.
-//     Se sprovvisto di estensione tenta il caricamento di qualsiasi formato conosciuto.
+//     Se sprovvisto di estensione tenta il caricamento di qualsiasi formato conosciuto,
+//     oppure, solo se indicato con un percorso (ad es. "/run/secrets/myapp" o "./myapp"),
+//     del file stesso rilevandone il formato dal contenuto.
+//     "-" indica lo standard input, il cui formato viene rilevato dal contenuto.
 //   - cfg: PUNTATORE a struttura configurazione da popolare.
 //
 // - errorWhenNotFound: true per generare un errore se il file non viene trovato.
+//
+// Non interpola, non risolve i secret e non valida i valori caricati, poiché il file potrebbe essere
+// solo uno dei livelli di override: al termine del caricamento usare Validate,
+// oppure caricare tramite Loader, che esegue Interpolate, ResolveSecrets e Validate.
 func LoadFile(filename string, cfg interface{}, errorWhenNotFound bool) (loadedFilename string, err error) {
+	loadedFilename, _, err = loadFileIncludes(filename, cfg, errorWhenNotFound)
+	return
+}
+
+// Come LoadFile, ritornando anche i file inclusi (vedi parseFile).
+func loadFileIncludes(filename string, cfg interface{}, errorWhenNotFound bool) (loadedFilename string, included []string, err error) {
+	if filename == "-" {
+		bb, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", nil, err
+		}
+
+		included, err = parseData(nil, "-", "-", "", bb, cfg, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		return filename, included, nil
+	}
+
 	fullpathFile, err := GetFileFullPath(filename)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return loadFile(fullpathFile, cfg, errorWhenNotFound)
+	return loadFile(fullpathFile, cfg, errorWhenNotFound, explicitPath(filename))
 }
 
 // Carica la configurazione da Systemd.
 // ref: https://systemd.io/CREDENTIALS/
 //
 //   - filename: deve essere un nome file, sprovvisto di percorso assoluto, situato in $CREDENTIALS_DIRECTORY.
-//     L'estensione viene ignorata, tentando il carimento di qualsiasi formato conosciuto.
+//     L'estensione viene ignorata, tentando il carimento di qualsiasi formato conosciuto;
+//     in mancanza viene caricato il file privo di estensione, rilevandone il formato dal contenuto.
 //   - cfg: PUNTATORE a struttura configurazione da popolare.
 //   - errorWhenNotFound: true per generare un errore se il file non viene trovato o se $CREDENTIALS_DIRECTORY non è settato.
 func LoadSystemdCredentials(filename string, cfg interface{}, errorWhenNotFound bool) (loadedFilename string, err error) {
+	loadedFilename, _, err = loadSystemdCredentials(filename, cfg, errorWhenNotFound)
+	return
+}
+
+// Come LoadSystemdCredentials, ritornando anche i file inclusi (vedi parseFile).
+func loadSystemdCredentials(filename string, cfg interface{}, errorWhenNotFound bool) (loadedFilename string, included []string, err error) {
 	path := os.Getenv("CREDENTIALS_DIRECTORY")
 	if path == "" {
 		if errorWhenNotFound {
-			return "", errors.New("systemd credential directory not found")
+			return "", nil, errors.New("systemd credential directory not found")
 		}
-		return "", nil
+		return "", nil, nil
 	}
 
 	// Rimuove l'eventuale estensione, permettendo un override di qualsiasi formato.
 	ext := filepath.Ext(filename)
-	switch ext {
-	case ".json":
-		fallthrough
-	case ".jsonc":
-		fallthrough
-	case ".yaml":
-		fallthrough
-	case ".toml":
+	if isKnownExt(ext) {
 		filename = strings.TrimSuffix(filename, ext)
 	}
 
 	fullpathFile := filepath.Join(path, filename)
 
-	return loadFile(fullpathFile, cfg, errorWhenNotFound)
+	return loadFile(fullpathFile, cfg, errorWhenNotFound, true)
+}
+
+// Carica la configurazione da tutti i file di formato conosciuto (.json, .jsonc, .json5, .yaml, .toml, .ini, .conf, .env, .hcl, .properties)
+// presenti in una directory (stile conf.d), in ordine lessicale, ciascuno a mo' di override dei precedenti.
+// I file nascosti (che iniziano per '.') e le sottodirectory vengono ignorati.
+//   - dir: se non ha percorso o lo ha relativo, sarà rispetto alla directory corrente;
+//     se ha percorso assoluto può anche iniziare per '~'.
+//   - cfg: PUNTATORE a struttura configurazione da popolare.
+//   - errorWhenNotFound: true per generare un errore se la directory non viene trovata.
+func LoadDir(dir string, cfg interface{}, errorWhenNotFound bool) (loadedFilenames []string, err error) {
+	fullpathDir, err := GetFileFullPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	loadedFilenames, _, err = loadDir(fullpathDir, cfg, errorWhenNotFound)
+	return
+}
+
+// Come LoadDir, con dir di percorso assoluto;
+// ritorna anche tutti i file caricati, compresi gli inclusi, in ordine di applicazione.
+func loadDir(fullpathDir string, cfg interface{}, errorWhenNotFound bool) (loadedFilenames, files []string, err error) {
+	entries, err := os.ReadDir(fullpathDir)
+	if err != nil {
+		if os.IsNotExist(err) && !errorWhenNotFound {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if !isKnownExt(filepath.Ext(name)) {
+			continue
+		}
+
+		loadedFilename, included, err := loadFile(filepath.Join(fullpathDir, name), cfg, true, true)
+		if err != nil {
+			return loadedFilenames, files, err
+		}
+		loadedFilenames = append(loadedFilenames, loadedFilename)
+		files = append(files, included...)
+		files = append(files, loadedFilename)
+	}
+
+	return loadedFilenames, files, nil
+}
+
+// Carica la configurazione da un file system astratto, ad es. embed.FS.
+//   - fsys: file system.
+//   - filename: percorso del file all'interno di fsys (vedi fs.ValidPath).
+//     Se sprovvisto di estensione tenta il caricamento di qualsiasi formato conosciuto,
+//     oppure, se situato in una sottodirectory, del file stesso rilevandone il formato dal contenuto.
+//   - cfg: PUNTATORE a struttura configurazione da popolare.
+//   - errorWhenNotFound: true per generare un errore se il file non viene trovato.
+//
+// Gli eventuali file inclusi sono cercati nel medesimo file system.
+func LoadFS(fsys fs.FS, filename string, cfg interface{}, errorWhenNotFound bool) (loadedFilename string, err error) {
+	if fsys == nil {
+		return "", errors.New("file system cannot be nil")
+	}
+
+	loadedFilename, _, err = loadFileFS(fsys, filename, cfg, errorWhenNotFound, explicitPath(filename))
+	return
+}
+
+// Carica la configurazione da un reader, ad es. os.Stdin.
+//   - r: reader da cui leggere i dati.
+//   - format: formato dei dati, "json", "jsonc", "json5", "yaml", "toml", "ini", "env", "hcl", "properties" (anche con il punto iniziale);
+//     stringa vuota per rilevarlo dal contenuto (i formati Ini, Dotenv, Hcl e properties non vengono rilevati).
+//   - cfg: PUNTATORE a struttura configurazione da popolare.
+//
+// Gli eventuali file inclusi sono relativi alla directory corrente.
+func LoadReader(r io.Reader, format string, cfg interface{}) error {
+	ext := ""
+	if format != "" {
+		ext = "." + strings.TrimPrefix(strings.ToLower(format), ".")
+	}
+
+	if ext != "" && !isKnownExt(ext) {
+		return fmt.Errorf("no decoder for %s format", format)
+	}
+
+	bb, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseData(nil, "-", "-", ext, bb, cfg, nil)
+	return err
 }
 
 // Funzione interna per caricare la configurazione da file.
 //   - filename: nome file con percorso assoluto.
-//     se senza estensione cerca di caricare .json, .jsonc, .yaml, .toml
+//     se senza estensione cerca di caricare .json, .jsonc, .json5, .yaml, .toml, .ini, .conf, .env, .hcl, .properties
 //     Se sprovvisto di estensione tenta il caricamento di qualsiasi formato conosciuto.
 //   - cfg: PUNTATORE a struttura configurazione da popolare.
 //   - errorWhenNotFound: true per generare un errore se il file non viene trovato.
-func loadFile(filename string, cfg interface{}, errorWhenNotFound bool) (loadedFilename string, err error) {
-	ext := filepath.Ext(filename)
+//   - exact: vedi findFile.
+//
+// Ritorna anche i file inclusi, in ordine di caricamento (vedi parseFile).
+func loadFile(filename string, cfg interface{}, errorWhenNotFound, exact bool) (loadedFilename string, included []string, err error) {
+	return loadFileFS(nil, filename, cfg, errorWhenNotFound, exact)
+}
+
+// Come loadFile, ma dal file system indicato; nil per il file system del sistema operativo.
+func loadFileFS(fsys fs.FS, filename string, cfg interface{}, errorWhenNotFound, exact bool) (loadedFilename string, included []string, err error) {
+	found, ext := findFile(fsys, filename, exact)
+	if found == "" {
+		if errorWhenNotFound {
+			return "", nil, errors.New("file not found: " + notFoundName(filename))
+		}
+		return "", nil, nil
+	}
+
+	included, err = parseFile(fsys, found, ext, cfg, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return found, included, nil
+}
+
+// Ritorna il nome file effettivo e la sua estensione,
+// cercando i formati conosciuti se filename ne è sprovvisto.
+//   - exact: true se filename indica proprio il file da caricare (ad es. una credenziale systemd):
+//     in mancanza dei formati conosciuti ritorna filename stesso, se esiste, con estensione vuota
+//     (formato da rilevare dal contenuto); false se filename è solo il nome base da cercare
+//     (ad es. nei percorsi standard), per non caricare file omonimi come eseguibili o README.
+//
+// Ritorna stringa vuota se il file non esiste.
+func findFile(fsys fs.FS, filename string, exact bool) (found, ext string) {
+	ext = filepath.Ext(filename)
+
+	if isKnownExt(ext) {
+		if !fileExistsFS(fsys, filename) {
+			return "", ""
+		}
+		return filename, ext
+	}
+
+	for _, ext := range knownExts {
+		if fileExistsFS(fsys, filename+ext) {
+			return filename + ext, ext
+		}
+	}
+
+	if exact && fileExistsFS(fsys, filename) {
+		return filename, ""
+	}
+
+	return "", ""
+}
+
+// Ritorna true se filename indica un file tramite il suo percorso, ad es. "/run/secrets/myapp" o "./myapp",
+// e non il solo nome base, ad es. "myapp" (vedi findFile).
+func explicitPath(filename string) bool {
+	return strings.Contains(filepath.ToSlash(filename), "/")
+}
+
+// Ritorna il nome file da riportare negli errori di file non trovato.
+func notFoundName(filename string) string {
+	if isKnownExt(filepath.Ext(filename)) {
+		return filename
+	}
+
+	return filename + strings.Join(knownExts, "/")
+}
+
+// Ritorna true se ext è l'estensione di un formato conosciuto.
+func isKnownExt(ext string) bool {
+	for _, known := range knownExts {
+		if ext == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parsa il file, caricando prima gli eventuali file inclusi.
+//   - fsys: file system da cui leggere; nil per il file system del sistema operativo.
+//   - includeChain: catena dei file che includono quello corrente, per individuare i cicli.
+//
+// Ritorna i file inclusi, anche indirettamente, nell'ordine in cui sono stati caricati.
+func parseFile(fsys fs.FS, filename, ext string, cfg interface{}, includeChain []string) (included []string, err error) {
+	for _, f := range includeChain {
+		if f == filename {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(includeChain, " -> "), filename)
+		}
+	}
+
+	// Descrizione del file da riportare negli errori.
+	name := filename
+	if len(includeChain) > 0 {
+		name += " (included from " + strings.Join(includeChain, " -> ") + ")"
+	}
+
+	var bb []byte
+	if fsys == nil {
+		bb, err = os.ReadFile(filename)
+	} else {
+		bb, err = fs.ReadFile(fsys, filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %s", name, err)
+	}
+
+	return parseData(fsys, filename, name, ext, bb, cfg, includeChain)
+}
+
+// Parsa i dati nel formato indicato, caricando prima gli eventuali file inclusi (vedi parsers.IncludeKey).
+// I percorsi inclusi sono relativi alla directory del file che li include
+// e possono contenere caratteri jolly (vedi filepath.Match).
+//   - filename: file da cui provengono i dati.
+//   - name: descrizione dei dati da riportare negli errori.
+//   - ext: estensione del formato; se vuota il formato viene rilevato dal contenuto.
+//
+// Ritorna i file inclusi, anche indirettamente, nell'ordine in cui sono stati caricati.
+// Gli errori di sintassi o di decodifica sono ritornati come *ParseError.
+func parseData(fsys fs.FS, filename, name, ext string, bb []byte, cfg interface{}, includeChain []string) (included []string, err error) {
+	var includes []string
+
+	src := bb
+
+	if ext == "" {
+		ext = parsers.DetectFormat(bb)
+		if ext == "" {
+			return nil, fmt.Errorf("cannot parse %s: unknown format", name)
+		}
+	}
 
 	switch ext {
 	case ".json":
 		fallthrough
 	case ".jsonc":
-		fallthrough
+		includes, bb, err = parsers.JsoncIncludes(bb)
+	case ".json5":
+		includes, bb, err = parsers.Json5Includes(bb)
 	case ".yaml":
-		fallthrough
+		includes, bb, err = parsers.YamlIncludes(bb)
 	case ".toml":
-		if !fileExists(filename) {
-			if errorWhenNotFound {
-				return "", errors.New("file not found: " + filename)
+		includes, bb, err = parsers.TomlIncludes(bb)
+	case ".ini", ".conf":
+		includes, bb, err = parsers.IniIncludes(bb)
+	case ".env":
+		includes, bb, err = parsers.DotenvIncludes(bb)
+	case ".hcl":
+		includes, bb, err = parsers.HclIncludes(bb)
+	case ".properties":
+		includes, bb, err = parsers.PropertiesIncludes(bb)
+	}
+
+	if err != nil {
+		return nil, newParseError(filename, ext, src, src, nil, err)
+	}
+
+	chain := append(includeChain[:len(includeChain):len(includeChain)], filename)
+
+	for _, include := range includes {
+		var matches []string
+
+		if fsys == nil {
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(filename), include)
 			}
-			return "", nil
-		}
-
-	default:
-		if fileExists(filename + ".json") {
-			ext = ".json"
-		} else if fileExists(filename + ".jsonc") {
-			ext = ".jsonc"
-		} else if fileExists(filename + ".yaml") {
-			ext = ".yaml"
-		} else if fileExists(filename + ".toml") {
-			ext = ".toml"
+			matches, err = filepath.Glob(include)
 		} else {
-			if errorWhenNotFound {
-				return "", errors.New("file not found: " + filename + ".json/.jsonc/.yaml/.toml")
+			include = path.Join(path.Dir(filename), include)
+			matches, err = fs.Glob(fsys, include)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: invalid include %s: %s", name, include, err)
+		}
+
+		pattern := strings.ContainsAny(include, "*?[")
+		if !pattern {
+			// Percorso senza caratteri jolly: il file deve esistere, anche se privo di estensione.
+			matches = []string{include}
+		}
+
+		for _, match := range matches {
+			found, includeExt := findFile(fsys, match, true)
+			if pattern && includeExt == "" {
+				continue // file di formato sconosciuto selezionato dal pattern
 			}
-			return "", nil
+			if found == "" {
+				return nil, fmt.Errorf("cannot parse %s: included file not found: %s", name, notFoundName(match))
+			}
+
+			sub, err := parseFile(fsys, found, includeExt, cfg, chain)
+			if err != nil {
+				return nil, err
+			}
+			included = append(included, sub...)
+			included = append(included, found)
 		}
-		filename += ext
 	}
 
 	// Parsa il file.
@@ -108,18 +400,34 @@ func loadFile(filename string, cfg interface{}, errorWhenNotFound bool) (loadedF
 	case ".json":
 		fallthrough
 	case ".jsonc":
-		err = parsers.LoadJsoncFile(filename, cfg)
+		err = parsers.LoadJsonc(bb, cfg)
+	case ".json5":
+		err = parsers.LoadJson5(bb, cfg)
 	case ".yaml":
-		err = parsers.LoadYamlFile(filename, cfg)
+		err = parsers.LoadYaml(bb, cfg)
 	case ".toml":
-		err = parsers.LoadTomlFile(filename, cfg)
+		err = parsers.LoadToml(bb, cfg)
+	case ".ini", ".conf":
+		err = parsers.LoadIni(bb, cfg)
+	case ".env":
+		err = parsers.LoadDotenv(bb, cfg)
+	case ".hcl":
+		err = parsers.LoadHcl(bb, cfg)
+	case ".properties":
+		err = parsers.LoadProperties(bb, cfg)
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("cannot parse %s: %s", filename, err)
+		return nil, newParseError(filename, ext, src, bb, cfg, err)
 	}
 
-	return filename, nil
+	return included, nil
+}
+
+// Opzioni di salvataggio su file (vedi SaveFileWithOptions).
+type SaveOptions struct {
+	Mode    os.FileMode // Permessi dei nuovi file, 0 per parsers.DefaultFileMode (0600); i file esistenti mantengono i propri.
+	Backups int         // Numero di copie di backup del file esistente (file.bak, file.bak.1, ...), 0 per nessuna.
 }
 
 // Salva la configurazione su file.
@@ -128,45 +436,82 @@ func loadFile(filename string, cfg interface{}, errorWhenNotFound bool) (loadedF
 //   - cfg: struttura configurazione da salvare.
 //   - defaults: (opzionale) struttura configurazione di default.
 //     se passata il file conterrà i soli valori che differiscono da questa struttura.
+//
+// I secret risolti da ResolveSecrets vengono salvati come riferimento originale.
+// Il file viene scritto in modo atomico, con le opzioni predefinite (vedi SaveFileWithOptions).
 func SaveFile(filename string, cfg interface{}, defaults interface{}) error {
+	return SaveFileWithOptions(filename, cfg, defaults, SaveOptions{})
+}
+
+// Salva la configurazione su file come SaveFile, con le opzioni indicate.
+// I dati vengono scritti in un file temporaneo, sincronizzato su disco e quindi rinominato sul file
+// di destinazione, che in caso di errore resta invariato; se il file esiste già ne vengono mantenuti
+// permessi e, se consentito all'utente corrente, proprietario.
+func SaveFileWithOptions(filename string, cfg interface{}, defaults interface{}, opts SaveOptions) error {
 	if cfg == nil {
 		return errors.New("config data cannot be nil")
 	}
 
-	var mapToSave interface{}
+	bb, err := MarshalDiff(cfg, defaults, filepath.Ext(filename))
+	if err == nil {
+		err = parsers.WriteFile(filename, bb, &parsers.WriteOptions{Mode: opts.Mode, Backups: opts.Backups})
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot save to %s: %s", filename, err)
+	}
+
+	return nil
+}
 
-	if defaults == nil {
-		var err error
+// Codifica la configurazione nel formato indicato, come salvata da SaveFile.
+//   - cfg: struttura configurazione da codificare.
+//   - defaults: (opzionale) struttura configurazione di default;
+//     se passata il documento conterrà i soli valori che differiscono da questa struttura
+//     (le chiavi modificate delle map, gli array modificati per intero, ad es. slice di struct),
+//     da caricare in sovrapposizione ai default.
+//   - format: formato del documento, "json", "jsonc", "json5", "yaml", "toml", "ini", "env", "hcl", "properties"
+//     (anche con il punto iniziale).
+//
+// I secret risolti da ResolveSecrets vengono codificati come riferimento originale.
+func MarshalDiff(cfg interface{}, defaults interface{}, format string) ([]byte, error) {
+	if cfg == nil {
+		return nil, errors.New("config data cannot be nil")
+	}
+
+	// I secret risolti non vengono mai salvati.
+	data, err := redactSecrets(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-		mapToSave, err = diff(defaults, cfg)
+	if defaults != nil {
+		data, err = diff(defaults, data)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	} else {
-		mapToSave = cfg
 	}
 
-	var err error
-	ext := filepath.Ext(filename)
-
-	switch ext {
-	case ".json":
-		fallthrough
-	case ".jsonc":
-		err = parsers.SaveJsonFile(filename, mapToSave)
+	switch "." + strings.TrimPrefix(strings.ToLower(format), ".") {
+	case ".json", ".jsonc":
+		return parsers.SaveJson(data)
+	case ".json5":
+		return parsers.SaveJson5(data)
 	case ".yaml":
-		err = parsers.SaveYamlFile(filename, mapToSave)
+		return parsers.SaveYaml(data)
 	case ".toml":
-		err = parsers.SaveTomlFile(filename, mapToSave)
-	default:
-		err = fmt.Errorf("no encoder for %s extension", ext)
-	}
-
-	if err != nil {
-		return fmt.Errorf("cannot save to %s: %s", filename, err)
+		return parsers.SaveToml(data)
+	case ".ini", ".conf":
+		return parsers.SaveIni(data)
+	case ".env":
+		return parsers.SaveDotenv(data)
+	case ".hcl":
+		return parsers.SaveHcl(data)
+	case ".properties":
+		return parsers.SaveProperties(data)
 	}
 
-	return nil
+	return nil, fmt.Errorf("no encoder for %s format", format)
 }
 
 // Ritorna il nome file completo di percorso assoluto.
@@ -197,6 +542,19 @@ func GetFileFullPath(filename string) (string, error) {
 	return filepath.Join(currDir, filename), nil
 }
 
+func fileExistsFS(fsys fs.FS, filename string) bool {
+	if fsys == nil {
+		return fileExists(filename)
+	}
+
+	info, err := fs.Stat(fsys, filename)
+	if err != nil {
+		return false
+	}
+
+	return !info.IsDir()
+}
+
 func fileExists(filename string) bool {
 	info, err := os.Stat(filename)
 	if os.IsNotExist(err) {
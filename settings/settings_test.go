@@ -1,6 +1,12 @@
 package settings
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -84,6 +90,40 @@ func TestLoadJsonc(t *testing.T) {
 	testLoad(parsers.LoadJsonc, jsc, t)
 }
 
+func TestLoadJson5(t *testing.T) {
+	js5 := `
+// Commento
+{
+	Main: {
+		/* Override */
+		ParamInt: +0xD,
+		'paramString': 'it\'s "quoted" \
+continued',
+		paramFloat: .5,
+	},
+	users: [
+		{ name: 'John', },
+	],
+}
+`
+	testLoad(parsers.LoadJson5, js5, t)
+
+	data := defaultSettings()
+
+	err := parsers.LoadJson5([]byte(js5), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamString != "it's \"quoted\" continued" || data.Main.ParamFloat != 0.5 || len(data.Users) != 1 {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	lines, err := parsers.Json5KeyLines([]byte(js5))
+	if err != nil || lines["main.paramfloat"] != 9 || lines["users[0].name"] != 12 {
+		t.Fatalf("unexpected lines %v %v", lines, err)
+	}
+}
+
 func TestLoadYaml(t *testing.T) {
 	yaml := `
 main:
@@ -102,6 +142,224 @@ Paramint = 13
 	testLoad(parsers.LoadToml, toml, t)
 }
 
+func TestLoadIni(t *testing.T) {
+	ini := `
+; Commento
+[Main]
+paramint = 13
+ParamString = "quoted \"value\""
+
+# Elementi di array come sezioni indicizzate.
+[users.0]
+name = John
+email = 'john@email'
+`
+	testLoad(parsers.LoadIni, ini, t)
+
+	data := defaultSettings()
+
+	err := parsers.LoadIni([]byte(ini), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamString != `quoted "value"` || len(data.Users) != 1 || data.Users[0].EMail != "john@email" {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	lines, err := parsers.IniKeyLines([]byte(ini))
+	if err != nil || lines["main"] != 3 || lines["main.paramstring"] != 5 || lines["users[0].email"] != 10 {
+		t.Fatalf("unexpected lines %v %v", lines, err)
+	}
+
+	err = parsers.LoadIni([]byte("[main]\nparamint = abc\n"), &data)
+	var valueErr *parsers.ValueError
+	if !errors.As(err, &valueErr) || valueErr.Key != "main.paramint" {
+		t.Fatalf("expected value error, got %v", err)
+	}
+
+	err = parsers.LoadIni([]byte("[main]\nparamunknown = 1\n"), &data)
+	if err == nil {
+		t.Fatal("expected unknown key error")
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	env := `
+# Commento
+MAIN_PARAMINT=13
+export main_paramString="quoted \"value\"" # commento
+USERS_0_NAME=John
+USERS_0_EMAIL='john@email'
+`
+	testLoad(parsers.LoadDotenv, env, t)
+
+	data := defaultSettings()
+
+	err := parsers.LoadDotenv([]byte(env), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamString != `quoted "value"` || len(data.Users) != 1 || data.Users[0].EMail != "john@email" {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	// Strutture, elenchi e mappe annidati.
+	type nested struct {
+		Main struct {
+			ParamSub struct {
+				ParamArray []int
+				ParamMap   map[string]string
+			}
+		}
+	}
+
+	var n nested
+	err = parsers.LoadDotenv([]byte("MAIN_PARAMSUB_PARAMARRAY=1,2,3\nMAIN_PARAMSUB_PARAMMAP_Key=value\n"), &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Main.ParamSub.ParamArray) != 3 || n.Main.ParamSub.ParamArray[2] != 3 || n.Main.ParamSub.ParamMap["Key"] != "value" {
+		t.Fatalf("unexpected values %+v", n)
+	}
+
+	lines, err := parsers.DotenvKeyLines([]byte(env))
+	if err != nil || lines["main.paramstring"] != 4 || lines["users[0].email"] != 6 {
+		t.Fatalf("unexpected lines %v %v", lines, err)
+	}
+}
+
+func TestLoadProperties(t *testing.T) {
+	props := `
+# Commento
+main.paramint = 13
+! Altro commento
+main.paramString: caff\u00e8 \
+    "corretto"
+users.0.name John
+users.0.email=john@email
+`
+	testLoad(parsers.LoadProperties, props, t)
+
+	data := defaultSettings()
+
+	err := parsers.LoadProperties([]byte(props), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamString != `caffè "corretto"` || len(data.Users) != 1 || data.Users[0].EMail != "john@email" {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	// Strutture, elenchi e mappe annidati.
+	type nested struct {
+		Main struct {
+			ParamSub struct {
+				ParamArray []int
+				ParamMap   map[string]string
+			}
+		}
+	}
+
+	var n nested
+	err = parsers.LoadProperties([]byte("main.paramSub.paramArray=1,2,3\nmain.paramSub.paramMap.key=value\nmain.paramSub.paramMap.a\\.b=c\n"), &n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(n.Main.ParamSub.ParamArray) != 3 || n.Main.ParamSub.ParamMap["key"] != "value" || n.Main.ParamSub.ParamMap["a.b"] != "c" {
+		t.Fatalf("unexpected values %+v", n)
+	}
+
+	lines, err := parsers.PropertiesKeyLines([]byte(props))
+	if err != nil || lines["main"] != 3 || lines["main.paramstring"] != 5 || lines["users[0].email"] != 8 {
+		t.Fatalf("unexpected lines %v %v", lines, err)
+	}
+
+	err = parsers.LoadProperties([]byte("main.paramint=abc\n"), &data)
+	var valueErr *parsers.ValueError
+	if !errors.As(err, &valueErr) || valueErr.Key != "main.paramint" {
+		t.Fatalf("expected value error, got %v", err)
+	}
+}
+
+func TestLoadHcl(t *testing.T) {
+	hcl := `
+# Commento
+Main {
+  paramint    = 13
+  ParamString = "quoted \"value\" $${ref}" // commento
+}
+
+/* Elementi di array come blocchi ripetuti. */
+users {
+  name  = "John"
+  email = "john@email"
+}
+users {
+  name = "Smith\n"
+}
+`
+	testLoad(parsers.LoadHcl, hcl, t)
+
+	data := defaultSettings()
+
+	err := parsers.LoadHcl([]byte(hcl), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamString != `quoted "value" ${ref}` || len(data.Users) != 2 || data.Users[1].Name != "Smith\n" {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	lines, err := parsers.HclKeyLines([]byte(hcl))
+	if err != nil || lines["main.paramint"] != 4 || lines["main"] != 3 || lines["users[1].name"] != 14 {
+		t.Fatalf("unexpected lines %v %v", lines, err)
+	}
+
+	// Blocchi con etichetta come chiavi di mappe.
+	var servers struct {
+		Servers map[string]struct {
+			Port  int
+			Hosts []string
+		}
+	}
+	err = parsers.LoadHcl([]byte("servers \"web\" {\n  port = 80\n  hosts = [\"a\", \"b\",]\n}\nservers \"db\" { port = 5432 }\n"), &servers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servers.Servers["web"].Port != 80 || len(servers.Servers["web"].Hosts) != 2 || servers.Servers["db"].Port != 5432 {
+		t.Fatalf("unexpected values %+v", servers)
+	}
+
+	err = parsers.LoadHcl([]byte("main {\n  paramint = \"abc\"\n}\n"), &data)
+	var valueErr *parsers.ValueError
+	if !errors.As(err, &valueErr) || valueErr.Key != "main.paramint" {
+		t.Fatalf("expected value error, got %v", err)
+	}
+
+	// Costrutti non supportati.
+	var syntaxErr *parsers.SyntaxError
+	for doc, msg := range map[string]string{
+		"paramint = var.x":                      "unsupported expression var",
+		"paramint = 1 + 2":                      "unsupported expression",
+		"paramint = true ? 1 : 2":               "unsupported expression",
+		"paramint = max(1, 2)":                  "function calls are not supported",
+		"paramint = [for x in [1]: x]":          "for expressions are not supported",
+		"paramstring = \"${main.paramint}\"":    "templates are not supported",
+		"paramstring = \"%{if true}x%{endif}\"": "templates are not supported",
+		"paramstring = <<EOT\nx\nEOT":           "heredoc strings are not supported",
+	} {
+		err = parsers.LoadHcl([]byte("main {\n  "+doc+"\n}\n"), &data)
+		if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("%s: expected syntax error %q, got %v", doc, msg, err)
+		}
+	}
+
+	err = parsers.LoadHcl([]byte("main {}\nmain {}\n"), &data)
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected duplicate block error, got %v", err)
+	}
+}
+
 func TestSave(t *testing.T) {
 	defaults := settingsUsersItem{
 		Name:  "foo",
@@ -155,6 +413,40 @@ func TestSaveJson(t *testing.T) {
 	}
 }
 
+func TestSaveJson5(t *testing.T) {
+	config := MySettings{
+		Main: settingsMain{ParamString: "it's"},
+		Users: []settingsUsersItem{
+			{Name: "foo", EMail: "bar"},
+		},
+	}
+
+	bb, err := parsers.SaveJson5(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js5 := `
+{
+	Main: {
+		ParamString: 'it\'s',
+		ParamBool: false,
+		ParamInt: 0,
+		ParamFloat: 0,
+	},
+	Users: [
+		{
+			Name: 'foo',
+			EMail: 'bar',
+		},
+	],
+}
+`
+	if strings.TrimSpace(string(bb)) != strings.TrimSpace(js5) {
+		t.Fatalf("mismatch:\n%s", bb)
+	}
+}
+
 func TestSaveYaml(t *testing.T) {
 	config := settingsUsersItem{
 		Name:  "foo",
@@ -195,6 +487,166 @@ EMail = 'bar'
 	}
 }
 
+func TestSaveIni(t *testing.T) {
+	config := MySettings{
+		Main: settingsMain{ParamString: " a, b "},
+		Users: []settingsUsersItem{
+			{Name: "foo", EMail: "bar"},
+			{Name: "baz"},
+		},
+	}
+
+	bb, err := parsers.SaveIni(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ini := `
+[Main]
+ParamString = " a, b "
+ParamBool = false
+ParamInt = 0
+ParamFloat = 0
+
+[Users.0]
+Name = foo
+EMail = bar
+
+[Users.1]
+Name = baz
+EMail =
+`
+	if strings.TrimSpace(string(bb)) != strings.TrimSpace(ini) {
+		t.Fatalf("mismatch:\n%s", bb)
+	}
+
+	var loaded MySettings
+	err = parsers.LoadIni(bb, &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Main.ParamString != config.Main.ParamString || len(loaded.Users) != 2 || loaded.Users[1].Name != "baz" {
+		t.Fatalf("unexpected values %+v", loaded)
+	}
+}
+
+func TestSaveDotenv(t *testing.T) {
+	config := MySettings{
+		Main: settingsMain{ParamString: "it's a test"},
+		Users: []settingsUsersItem{
+			{Name: "foo", EMail: "bar"},
+		},
+	}
+
+	bb, err := parsers.SaveDotenv(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := `
+MAIN_PARAMSTRING="it's a test"
+MAIN_PARAMBOOL=false
+MAIN_PARAMINT=0
+MAIN_PARAMFLOAT=0
+USERS_0_NAME=foo
+USERS_0_EMAIL=bar
+`
+	if strings.TrimSpace(string(bb)) != strings.TrimSpace(env) {
+		t.Fatalf("mismatch:\n%s", bb)
+	}
+
+	var loaded MySettings
+	err = parsers.LoadDotenv(bb, &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Main.ParamString != config.Main.ParamString || len(loaded.Users) != 1 || loaded.Users[0].EMail != "bar" {
+		t.Fatalf("unexpected values %+v", loaded)
+	}
+}
+
+func TestSaveProperties(t *testing.T) {
+	config := MySettings{
+		Main: settingsMain{ParamString: "caffè, corretto"},
+		Users: []settingsUsersItem{
+			{Name: "foo", EMail: "bar"},
+		},
+	}
+
+	bb, err := parsers.SaveProperties(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	props := `
+Main.ParamString="caff\u00e8, corretto"
+Main.ParamBool=false
+Main.ParamInt=0
+Main.ParamFloat=0
+
+Users.0.Name=foo
+Users.0.EMail=bar
+`
+	if strings.TrimSpace(string(bb)) != strings.TrimSpace(props) {
+		t.Fatalf("mismatch:\n%s", bb)
+	}
+
+	var loaded MySettings
+	err = parsers.LoadProperties(bb, &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Main.ParamString != config.Main.ParamString || len(loaded.Users) != 1 || loaded.Users[0].EMail != "bar" {
+		t.Fatalf("unexpected values %+v", loaded)
+	}
+}
+
+func TestSaveHcl(t *testing.T) {
+	config := MySettings{
+		Main: settingsMain{ParamString: "a \"b\"\n${c}"},
+		Users: []settingsUsersItem{
+			{Name: "foo", EMail: "bar"},
+			{Name: "baz"},
+		},
+	}
+
+	bb, err := parsers.SaveHcl(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hcl := `
+Main {
+  ParamString = "a \"b\"\n$${c}"
+  ParamBool = false
+  ParamInt = 0
+  ParamFloat = 0
+}
+
+Users {
+  Name = "foo"
+  EMail = "bar"
+}
+
+Users {
+  Name = "baz"
+  EMail = ""
+}
+`
+	if strings.TrimSpace(string(bb)) != strings.TrimSpace(hcl) {
+		t.Fatalf("mismatch:\n%s", bb)
+	}
+
+	var loaded MySettings
+	err = parsers.LoadHcl(bb, &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Main.ParamString != config.Main.ParamString || len(loaded.Users) != 2 || loaded.Users[1].Name != "baz" {
+		t.Fatalf("unexpected values %+v", loaded)
+	}
+}
+
 func TestMultiLoad(t *testing.T) {
 	yaml := `
 main:
@@ -226,3 +678,204 @@ main:
 		t.Fatal("override non settati")
 	}
 }
+
+func TestYamlCaseInsensitive(t *testing.T) {
+	type yamlSettings struct {
+		Main   settingsMain
+		Labels map[string]string
+		Users  []settingsUsersItem
+	}
+
+	src := `
+Users:
+  - &john
+    NAME: John
+    eMail: "mail: john"
+  - <<: *john
+    Name: Smith
+Main:
+  ParamString: |
+    Key: Value
+    other: line
+  paramINT: 13
+Labels:
+  Key: "a: b"
+  key: c
+`
+
+	data := yamlSettings{}
+
+	err := parsers.LoadYaml([]byte(src), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Main.ParamString != "Key: Value\nother: line\n" || data.Main.ParamInt != 13 {
+		t.Fatalf("unexpected main %+v", data.Main)
+	}
+	if data.Labels["Key"] != "a: b" || data.Labels["key"] != "c" {
+		t.Fatalf("map keys altered %v", data.Labels)
+	}
+	if len(data.Users) != 2 || data.Users[1].Name != "Smith" || data.Users[1].EMail != "mail: john" {
+		t.Fatalf("unexpected users %+v", data.Users)
+	}
+
+	err = parsers.LoadYaml([]byte("Main:\n  paramInit: 1\n"), &data)
+	if err == nil || !strings.Contains(err.Error(), "line 2: field paramInit not found") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestJsoncTrailingCommas(t *testing.T) {
+	jsonc := `{
+	"main": {
+		"paramInt": 13, // commento, con virgola
+		"paramString": "a, }", /* commento
+		multiriga */
+	},
+	"users": [
+		{ "name": "foo", },
+	],
+}`
+
+	data := defaultSettings()
+
+	err := parsers.LoadJsonc([]byte(jsonc), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Main.ParamInt != 13 || data.Main.ParamString != "a, }" || len(data.Users) != 1 || data.Users[0].Name != "foo" {
+		t.Fatalf("unexpected values %+v", data)
+	}
+
+	// Le posizioni degli errori corrispondono al documento originale.
+	err = parsers.LoadJsonc([]byte(strings.Replace(jsonc, `"foo"`, `1`, 1)), &data)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || int(typeErr.Offset) != strings.Index(jsonc, `"foo"`)+1 {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSaveFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.yaml")
+
+	data := defaultSettings()
+
+	// Nuovo file: permessi predefiniti.
+	err := SaveFile(filename, data, MySettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil || info.Mode().Perm() != parsers.DefaultFileMode {
+		t.Fatalf("unexpected mode %v %v", info.Mode(), err)
+	}
+
+	// File esistente: permessi mantenuti, backup ruotati.
+	err = os.Chmod(filename, 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SaveOptions{Mode: 0600, Backups: 2}
+	for i := 1; i <= 3; i++ {
+		data.Main.ParamInt = i
+		err = SaveFileWithOptions(filename, data, MySettings{}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err = os.Stat(filename)
+	if err != nil || info.Mode().Perm() != 0640 {
+		t.Fatalf("mode not preserved %v %v", info.Mode(), err)
+	}
+
+	for file, paramInt := range map[string]int{"app.yaml": 3, "app.yaml.bak": 2, "app.yaml.bak.1": 1} {
+		var loaded MySettings
+		_, err = LoadFile(filepath.Join(dir, file), &loaded, true)
+		if err != nil || loaded.Main.ParamInt != paramInt {
+			t.Fatalf("%s: unexpected value %d %v", file, loaded.Main.ParamInt, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("unexpected files %v %v", entries, err)
+	}
+
+	// Link simbolico: viene aggiornato il file a cui punta.
+	link := filepath.Join(dir, "link.yaml")
+	err = os.Symlink(filename, link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data.Main.ParamInt = 4
+	err = SaveFile(link, data, MySettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded MySettings
+	_, err = LoadFile(filename, &loaded, true)
+	if err != nil || loaded.Main.ParamInt != 4 {
+		t.Fatalf("link target not updated %d %v", loaded.Main.ParamInt, err)
+	}
+	if info, err = os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("link replaced")
+	}
+}
+
+func TestMarshalDiff(t *testing.T) {
+	defaults := defaultSettings()
+
+	cfg := defaultSettings()
+	cfg.Main.ParamInt = 99
+	cfg.Users[1].EMail = "smith@email"
+	cfg.Users = append(cfg.Users, settingsUsersItem{Name: "Ann"})
+
+	for _, format := range []string{"json", "jsonc", "json5", "yaml", "toml", "ini", "env", "hcl", ".properties"} {
+		bb, err := MarshalDiff(cfg, defaults, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		// Solo i valori modificati.
+		lower := strings.ToLower(string(bb))
+		if !strings.Contains(lower, "99") || strings.Contains(lower, "parambool") || strings.Contains(lower, "paramstring") {
+			t.Fatalf("%s: unexpected document\n%s", format, bb)
+		}
+
+		loaded := defaultSettings()
+		err = LoadReader(bytes.NewReader(bb), format, &loaded)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, bb)
+		}
+		if !reflect.DeepEqual(loaded, cfg) {
+			t.Fatalf("%s: unexpected values %+v\n%s", format, loaded, bb)
+		}
+	}
+
+	_, err := MarshalDiff(cfg, defaults, "xml")
+	if err == nil {
+		t.Fatal("unknown format not detected")
+	}
+
+	// SaveFile con i default salva le sole differenze.
+	filename := filepath.Join(t.TempDir(), "app.yaml")
+	err = SaveFile(filename, cfg, defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(bb), "ParamString") {
+		t.Fatalf("unexpected document\n%s", bb)
+	}
+}
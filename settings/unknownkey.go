@@ -0,0 +1,235 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Chiave presente nel file di configurazione ma non prevista dalla struttura destinataria.
+type UnknownKeyError struct {
+	Key        string   // Nome della chiave sconosciuta.
+	Suggestion string   // Chiave valida più simile, vuota se nessuna è sufficientemente simile.
+	Valid      []string // Chiavi valide allo stesso livello.
+	Err        error    // Errore originale del decoder.
+}
+
+func (e *UnknownKeyError) Error() string {
+	s := fmt.Sprintf("unknown key %q", e.Key)
+
+	if e.Suggestion != "" {
+		s += fmt.Sprintf(", did you mean %q?", e.Suggestion)
+	}
+	if len(e.Valid) > 0 {
+		s += " Valid keys: " + strings.Join(e.Valid, ", ")
+	}
+
+	return s
+}
+
+func (e *UnknownKeyError) Unwrap() error {
+	return e.Err
+}
+
+var unknownFieldRegexp = regexp.MustCompile(`unknown field "([^"]*)"|field (\S+) not found in type`)
+
+// Ritorna il nome della chiave sconosciuta segnalata dal decoder;
+// ok è false se l'errore è di altro tipo.
+func unknownFieldName(err error) (name string, ok bool) {
+	var tomlStrictErr *toml.StrictMissingError
+	if errors.As(err, &tomlStrictErr) {
+		if len(tomlStrictErr.Errors) > 0 {
+			if key := tomlStrictErr.Errors[0].Key(); len(key) > 0 {
+				return key[len(key)-1], true
+			}
+		}
+		return "", true
+	}
+
+	m := unknownFieldRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return "", false
+	}
+
+	return m[1] + m[2], true
+}
+
+// Individua la prima chiave del documento (in ordine di riga) non prevista dal tipo t.
+//   - lines: chiavi del documento con la rispettiva riga (vedi keyLines).
+//   - name: nome della chiave segnalata dal decoder, se disponibile.
+//
+// Ritorna il percorso della chiave e l'errore descrittivo; nil se non individuata.
+func findUnknownKey(t reflect.Type, lines map[string]int, name string, err error) (path string, line int, ukErr *UnknownKeyError) {
+	for key, l := range lines {
+		parent, segment, valid, ok := checkKeyPath(t, key)
+		if ok {
+			continue
+		}
+		if name != "" && !strings.EqualFold(segment, name) {
+			continue
+		}
+		if name != "" {
+			segment = name
+		}
+
+		fullPath := joinPath(parent, segment)
+		if ukErr != nil && (l > line || l == line && (len(fullPath) > len(path) || len(fullPath) == len(path) && fullPath >= path)) {
+			continue
+		}
+
+		path = fullPath
+		line = l
+		ukErr = &UnknownKeyError{
+			Key:        segment,
+			Suggestion: suggestKey(segment, valid),
+			Valid:      valid,
+			Err:        err,
+		}
+	}
+
+	return path, line, ukErr
+}
+
+// Verifica che il percorso (in minuscolo, vedi keyLines) sia previsto dal tipo t.
+// Se non lo è ritorna il percorso del livello superiore, con i nomi dei campi,
+// la chiave non prevista e le chiavi valide a quel livello.
+func checkKeyPath(t reflect.Type, key string) (parent, segment string, valid []string, ok bool) {
+	for _, part := range strings.Split(key, ".") {
+		name, indexes, _ := strings.Cut(part, "[")
+		if indexes != "" {
+			indexes = "[" + indexes
+		}
+
+		// Elementi di elenchi senza indice, ad es. i blocchi Hcl non ripetuti:
+		// si tratta del primo elemento.
+		if k := indirectType(t).Kind(); k == reflect.Slice || k == reflect.Array {
+			t = indirectType(t).Elem()
+			parent += "[0]"
+		}
+
+		var display string
+		t, display, valid, ok = resolveKey(t, name)
+		if !ok {
+			return parent, name, valid, false
+		}
+		parent = joinPath(parent, display)
+
+		// Indici degli elementi di array.
+		for _, index := range strings.SplitAfter(indexes, "]") {
+			if index == "" {
+				continue
+			}
+
+			t = indirectType(t)
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array:
+				t = t.Elem()
+			case reflect.Interface:
+			default:
+				return parent, index, nil, false
+			}
+			parent += index
+		}
+	}
+
+	return "", "", nil, true
+}
+
+// Ritorna il tipo della chiave name del tipo t e il nome da riportare negli errori;
+// se la chiave non è prevista ritorna le chiavi valide.
+func resolveKey(t reflect.Type, name string) (reflect.Type, string, []string, bool) {
+	t = indirectType(t)
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var valid []string
+		for _, field := range keyFields(t) {
+			if strings.EqualFold(field.Name, name) {
+				return field.Type, lowerFirst(field.Name), nil, true
+			}
+			valid = append(valid, lowerFirst(field.Name))
+		}
+		return nil, "", valid, false
+
+	case reflect.Map:
+		return t.Elem(), name, nil, true
+
+	case reflect.Interface:
+		return t, name, nil, true
+	}
+
+	return nil, "", nil, false
+}
+
+// Ritorna i campi esportati della struttura, includendo quelli delle strutture embedded.
+func keyFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, keyFields(indirectType(field.Type))...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// Ritorna la chiave valida più simile a key, se sufficientemente simile.
+func suggestKey(key string, valid []string) string {
+	best := ""
+	bestDistance := (utf8.RuneCountInString(key)+1)/2 + 1
+
+	for _, candidate := range valid {
+		d := editDistance(strings.ToLower(key), strings.ToLower(candidate))
+		if d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+// Distanza di Levenshtein tra due stringhe.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,106 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/modulo-srl/mu-config/settings/parsers"
+)
+
+// Documento di configurazione in modifica (vedi UpdateFile).
+type Document struct {
+	ext string
+	bb  []byte
+}
+
+// Imposta il valore al percorso indicato, mantenendo commenti, ordine e formattazione del documento.
+//   - path: percorso della chiave come per Provenance.Origin, ad es. "main.paramInt", "users[1].name";
+//     le chiavi esistenti sono individuate senza distinzione tra maiuscole e minuscole,
+//     quelle mancanti vengono aggiunte; un indice pari alla lunghezza dell'array aggiunge un elemento.
+//   - value: valore da impostare, codificato come da Json (strutture comprese).
+func (d *Document) Set(path string, value interface{}) error {
+	var bb []byte
+	var err error
+
+	switch d.ext {
+	case ".json", ".jsonc":
+		bb, err = parsers.SetJsonc(d.bb, path, value)
+	case ".yaml":
+		bb, err = parsers.SetYaml(d.bb, path, value)
+	case ".toml":
+		bb, err = parsers.SetToml(d.bb, path, value)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set %s: %w", path, err)
+	}
+
+	d.bb = bb
+	return nil
+}
+
+// Ritorna il contenuto corrente del documento.
+func (d *Document) Bytes() []byte {
+	return d.bb
+}
+
+// Modifica un file di configurazione esistente (.json, .jsonc, .yaml, .toml) mantenendone
+// commenti, ordine e formattazione, a differenza di SaveFile che lo riscrive per intero.
+//   - filename: se non ha percorso o lo ha relativo, sarà rispetto alla directory corrente;
+//     se ha percorso assoluto può anche iniziare per '~'.
+//   - update: funzione che applica le modifiche al documento (vedi Document.Set);
+//     se ritorna errore il file resta invariato.
+//
+// Il file viene riscritto in modo atomico (vedi SaveFileWithOptions), solo se modificato
+// e se il risultato è ancora valido.
+func UpdateFile(filename string, update func(doc *Document) error) error {
+	fullpathFile, err := GetFileFullPath(filename)
+	if err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(fullpathFile)
+	switch ext {
+	case ".json", ".jsonc", ".yaml", ".toml":
+	default:
+		return fmt.Errorf("cannot update %s: unsupported format", filename)
+	}
+
+	bb, err := os.ReadFile(fullpathFile)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %w", filename, err)
+	}
+
+	doc := &Document{ext: ext, bb: bb}
+	err = update(doc)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %w", filename, err)
+	}
+
+	if bytes.Equal(doc.bb, bb) {
+		return nil
+	}
+
+	// Il documento modificato deve essere ancora decodificabile.
+	var data map[string]interface{}
+	_, err = parseData(nil, fullpathFile, fullpathFile, ext, doc.bb, &data, nil)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %w", filename, err)
+	}
+
+	err = parsers.WriteFile(fullpathFile, doc.bb, nil)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// Imposta il valore al percorso indicato in un file di configurazione esistente,
+// mantenendone commenti, ordine e formattazione (vedi UpdateFile e Document.Set).
+func SetInFile(filename, path string, value interface{}) error {
+	return UpdateFile(filename, func(doc *Document) error {
+		return doc.Set(path, value)
+	})
+}
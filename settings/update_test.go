@@ -0,0 +1,172 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name: "app.jsonc",
+			src: `// Configurazione
+{
+	"main": {
+		"paramString": "old", // commento
+		"paramInt": 12,
+	},
+	/* utenti */
+	"users": [
+		{"name": "John", "eMail": "john@email"},
+		{"name": "Smith"}
+	]
+}
+`,
+			expected: `// Configurazione
+{
+	"main": {
+		"paramString": "new", // commento
+		"paramInt": 42,
+		"paramBool": true
+	},
+	/* utenti */
+	"users": [
+		{"name": "John", "eMail": "john@email"},
+		{"name": "Smith", "eMail": "smith@email"},
+		{
+			"Name": "Ann",
+			"EMail": ""
+		}
+	]
+}
+`,
+		},
+		{
+			name: "app.yaml",
+			src: `# Configurazione
+main:
+  paramString: old # commento
+  paramInt: 12
+
+# utenti
+users:
+  - name: John
+    eMail: john@email
+  - name: Smith
+`,
+			expected: `# Configurazione
+main:
+  paramString: new # commento
+  paramInt: 42
+  paramBool: true
+
+# utenti
+users:
+  - name: John
+    eMail: john@email
+  - name: Smith
+    eMail: smith@email
+  - Name: Ann
+    EMail: ""
+`,
+		},
+		{
+			name: "app.toml",
+			src: `# Configurazione
+[main]
+paramString = 'old' # commento
+paramInt = 12
+
+# utenti
+[[users]]
+name = "John"
+eMail = "john@email"
+
+[[users]]
+name = "Smith"
+`,
+			expected: `# Configurazione
+[main]
+paramString = "new" # commento
+paramInt = 42
+paramBool = true
+
+# utenti
+[[users]]
+name = "John"
+eMail = "john@email"
+
+[[users]]
+name = "Smith"
+eMail = "smith@email"
+
+[[users]]
+Name = "Ann"
+EMail = ""
+`,
+		},
+	}
+
+	for _, test := range tests {
+		filename := filepath.Join(dir, test.name)
+		writeTestFiles(t, dir, map[string]string{test.name: test.src})
+
+		err := UpdateFile(filename, func(doc *Document) error {
+			for path, value := range map[string]interface{}{
+				"main.paramString": "new",
+				"MAIN.PARAMINT":    42,
+				"main.paramBool":   true,
+				"users[1].eMail":   "smith@email",
+			} {
+				err := doc.Set(path, value)
+				if err != nil {
+					return err
+				}
+			}
+			return doc.Set("users[2]", settingsUsersItem{Name: "Ann"})
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		bb, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != test.expected {
+			t.Fatalf("%s: unexpected content\n%s", test.name, bb)
+		}
+
+		var data MySettings
+		_, err = LoadFile(filename, &data, true)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if data.Main.ParamInt != 42 || len(data.Users) != 3 || data.Users[1].EMail != "smith@email" {
+			t.Fatalf("%s: unexpected values %+v", test.name, data)
+		}
+
+		// Errore: il file resta invariato.
+		err = SetInFile(filename, "users[9].name", "x")
+		if err == nil {
+			t.Fatalf("%s: index out of range not detected", test.name)
+		}
+		bb2, _ := os.ReadFile(filename)
+		if string(bb2) != string(bb) {
+			t.Fatalf("%s: file modified on error", test.name)
+		}
+	}
+
+	writeTestFiles(t, dir, map[string]string{"app.ini": "[main]\nparamint = 1\n"})
+	err := SetInFile(filepath.Join(dir, "app.ini"), "main.paramInt", 2)
+	if err == nil {
+		t.Fatal("unsupported format not detected")
+	}
+}
@@ -0,0 +1,319 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/modulo-srl/mu-config/settings/parsers"
+	"gitlab.com/c0b/go-ordered-json"
+)
+
+// Conflitto rilevato da Upgrade: valore personalizzato dall'utente e modificato anche nei nuovi default.
+// Viene mantenuto il valore dell'utente.
+// I valori sono nella forma decodificata da Json (numeri come json.Number, oggetti come *ordered.OrderedMap).
+type UpgradeConflict struct {
+	Path string      // Percorso del campo, ad es. "Main.ParamInt" (vedi Provenance).
+	Old  interface{} // Default precedente.
+	New  interface{} // Nuovo default.
+	User interface{} // Valore dell'utente.
+}
+
+func (c *UpgradeConflict) String() string {
+	value := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+
+	return fmt.Sprintf("%s: customized to %s, default changed from %s to %s",
+		c.Path, value(c.User), value(c.Old), value(c.New))
+}
+
+// Valore del campo da aggiornare al nuovo default.
+type upgradeUpdate struct {
+	path  string
+	value interface{}
+}
+
+// Chiave assente in una delle map confrontate.
+var upgradeMissing = &struct{}{}
+
+// Aggiorna il file di configurazione dell'utente al cambio dei default, con un merge a tre vie
+// tra i default precedenti, i nuovi default e i valori del file:
+//   - i valori non personalizzati (uguali ai default precedenti) seguono i nuovi default;
+//   - i valori personalizzati vengono mantenuti;
+//   - se un valore personalizzato è cambiato anche nei default viene mantenuto quello dell'utente
+//     e riportato tra i conflitti.
+//
+// Gli array (ad es. slice di struct) sono confrontati per intero, come da SaveFile.
+// I file Json/c, Yaml e Toml vengono modificati mantenendo commenti, ordine e formattazione (vedi UpdateFile),
+// aggiornando i soli valori non personalizzati presenti nel file; gli altri formati vengono riscritti
+// con le sole differenze dai nuovi default (vedi MarshalDiff).
+//   - oldDefaults, newDefaults: strutture configurazione di default, dello stesso tipo.
+//   - filename: file dell'utente (vedi LoadFile), che deve esistere.
+func Upgrade(oldDefaults, newDefaults interface{}, filename string) ([]UpgradeConflict, error) {
+	if oldDefaults == nil || newDefaults == nil {
+		return nil, errors.New("defaults cannot be nil")
+	}
+
+	t := reflect.TypeOf(newDefaults)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.TypeOf(oldDefaults) != reflect.TypeOf(newDefaults) {
+		return nil, errors.New("defaults must be of the same type")
+	}
+
+	fullpathFile, err := GetFileFullPath(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	found, ext := findFile(nil, fullpathFile, explicitPath(filename))
+	if found == "" {
+		return nil, errors.New("file not found: " + notFoundName(fullpathFile))
+	}
+
+	bb, err := os.ReadFile(found)
+	if err != nil {
+		return nil, err
+	}
+	if ext == "" {
+		ext = parsers.DetectFormat(bb)
+	}
+
+	// Configurazione dell'utente, come caricata con i default precedenti.
+	userCfg := reflect.New(t).Interface()
+	err = cloneData(oldDefaults, userCfg)
+	if err != nil {
+		return nil, err
+	}
+	_, err = parseFile(nil, found, ext, userCfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	trees := make([]*ordered.OrderedMap, 3)
+	for i, data := range []interface{}{oldDefaults, newDefaults, userCfg} {
+		trees[i], err = orderedTree(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var updates []upgradeUpdate
+	var conflicts []UpgradeConflict
+	upgradeValues("", trees[0], trees[1], trees[2], &updates, &conflicts)
+
+	if len(updates) == 0 {
+		return conflicts, nil
+	}
+
+	switch ext {
+	case ".json", ".jsonc", ".yaml", ".toml":
+		// Sono aggiornati i soli valori presenti nel file: gli altri seguono già i nuovi default.
+		lines := keyLines(ext, bb)
+
+		// Valori del file, per mantenere la forma delle chiavi negli oggetti e negli array sostituiti.
+		var existing map[string]interface{}
+		_, err = parseData(nil, found, found, ext, bb, &existing, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		err = UpdateFile(found, func(doc *Document) error {
+			for _, u := range updates {
+				if _, ok := lines[strings.ToLower(u.path)]; !ok {
+					continue
+				}
+				err := doc.Set(u.path, matchKeys(u.value, fileValue(existing, u.path)))
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	default:
+		// Personalizzazioni dell'utente applicate ai nuovi default, come al caricamento.
+		var customized *ordered.OrderedMap
+		customized, err = diff(oldDefaults, userCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		merged := reflect.New(t).Interface()
+		err = cloneData(newDefaults, merged)
+		if err == nil {
+			err = cloneData(customized, merged)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var out []byte
+		out, err = MarshalDiff(merged, newDefaults, ext)
+		if err == nil {
+			err = parsers.WriteFile(found, out, nil)
+		}
+		if err != nil {
+			err = fmt.Errorf("cannot save to %s: %s", found, err)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return conflicts, nil
+}
+
+// Confronta ricorsivamente i valori al percorso indicato, riportando gli aggiornamenti e i conflitti.
+func upgradeValues(path string, oldValue, newValue, userValue interface{}, updates *[]upgradeUpdate, conflicts *[]UpgradeConflict) {
+	oldMap, ok1 := oldValue.(*ordered.OrderedMap)
+	newMap, ok2 := newValue.(*ordered.OrderedMap)
+	userMap, ok3 := userValue.(*ordered.OrderedMap)
+
+	if ok1 && ok2 && ok3 {
+		// Chiavi dei nuovi default, seguite da quelle presenti solo nei valori dell'utente.
+		keys := []string{}
+		seen := make(map[string]bool)
+		for _, m := range []*ordered.OrderedMap{newMap, userMap} {
+			iter := m.EntriesIter()
+			for {
+				pair, ok := iter()
+				if !ok {
+					break
+				}
+				if !seen[pair.Key] {
+					seen[pair.Key] = true
+					keys = append(keys, pair.Key)
+				}
+			}
+		}
+
+		get := func(m *ordered.OrderedMap, key string) interface{} {
+			if v, ok := m.GetValue(key); ok {
+				return v
+			}
+			return upgradeMissing
+		}
+
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			upgradeValues(child, get(oldMap, key), get(newMap, key), get(userMap, key), updates, conflicts)
+		}
+		return
+	}
+
+	switch {
+	case reflect.DeepEqual(newValue, oldValue) || reflect.DeepEqual(userValue, newValue):
+		// Default invariato, o già uguale al valore dell'utente.
+
+	case newValue == upgradeMissing || userValue == upgradeMissing:
+		// Chiave rimossa dai nuovi default o non presente tra i valori dell'utente.
+
+	case reflect.DeepEqual(userValue, oldValue):
+		// Valore non personalizzato: segue il nuovo default.
+		*updates = append(*updates, upgradeUpdate{path: path, value: newValue})
+
+	default:
+		old := oldValue
+		if old == upgradeMissing {
+			old = nil
+		}
+		*conflicts = append(*conflicts, UpgradeConflict{Path: path, Old: old, New: newValue, User: userValue})
+	}
+}
+
+// Ritorna il valore al percorso indicato (vedi upgradeValues) dei valori decodificati del file,
+// individuando le chiavi senza distinzione tra maiuscole e minuscole; nil se assente.
+func fileValue(v interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		v = nil
+		for k, value := range m {
+			if strings.EqualFold(k, key) {
+				v = value
+				break
+			}
+		}
+	}
+
+	return v
+}
+
+// Riporta le chiavi degli oggetti di value nella forma in cui sono scritte nei valori del file (refs),
+// ad es. "eMail" anziché il nome del campo "EMail"; per gli elementi degli array si considerano
+// anche le chiavi degli altri elementi. Le chiavi assenti dal file restano invariate.
+func matchKeys(value interface{}, refs ...interface{}) interface{} {
+	switch v := value.(type) {
+	case *ordered.OrderedMap:
+		m := ordered.NewOrderedMap()
+
+		iter := v.EntriesIter()
+		for {
+			pair, ok := iter()
+			if !ok {
+				break
+			}
+
+			key := pair.Key
+			var children []interface{}
+			for _, ref := range refs {
+				refMap, _ := ref.(map[string]interface{})
+				for k, child := range refMap {
+					if !strings.EqualFold(k, pair.Key) {
+						continue
+					}
+					if children == nil {
+						key = k
+					}
+					children = append(children, child)
+				}
+			}
+
+			m.Set(key, matchKeys(pair.Value, children...))
+		}
+		return m
+
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			// Elemento corrispondente, seguito dagli altri elementi.
+			var children []interface{}
+			for _, ref := range refs {
+				refList, _ := ref.([]interface{})
+				if i < len(refList) {
+					children = append(children, refList[i])
+				}
+			}
+			for _, ref := range refs {
+				refList, _ := ref.([]interface{})
+				for j, child := range refList {
+					if j != i {
+						children = append(children, child)
+					}
+				}
+			}
+
+			items[i] = matchKeys(item, children...)
+		}
+		return items
+	}
+
+	return value
+}
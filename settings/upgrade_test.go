@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	oldDefaults := defaultSettings()
+
+	newDefaults := defaultSettings()
+	newDefaults.Main.ParamInt = 20       // non personalizzato: segue il nuovo default
+	newDefaults.Main.ParamFloat = 2.5    // personalizzato: conflitto
+	newDefaults.Main.ParamString = "new" // non personalizzato, assente dal file parziale
+	newDefaults.Users[1].EMail = "smith@email"
+
+	files := map[string]string{
+		// Configurazione completa salvata con i default precedenti.
+		"full.yaml": `# utente
+main:
+  paramString: "ParamValue \n \"test\""
+  paramBool: false # personalizzato
+  paramInt: 12
+  paramFloat: 3.5
+users:
+  - name: John
+    eMail: john@email
+  - name: Smith
+`,
+		// Override parziale, in un formato riscritto per intero.
+		"partial.ini": `[main]
+parambool = false
+paramint = 12
+paramfloat = 3.5
+`,
+	}
+	dir := t.TempDir()
+	writeTestFiles(t, dir, files)
+
+	expected := map[string]string{
+		"full.yaml": `# utente
+main:
+  paramString: new
+  paramBool: false # personalizzato
+  paramInt: 20
+  paramFloat: 3.5
+users:
+  - name: John
+    eMail: john@email
+  - name: Smith
+    eMail: smith@email
+`,
+		"partial.ini": `[Main]
+ParamBool = false
+ParamFloat = 3.5
+`,
+	}
+
+	for name := range files {
+		filename := filepath.Join(dir, name)
+
+		conflicts, err := Upgrade(oldDefaults, newDefaults, filename)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(conflicts) != 1 || conflicts[0].Path != "Main.ParamFloat" {
+			t.Fatalf("%s: unexpected conflicts %v", name, conflicts)
+		}
+		if s := conflicts[0].String(); s != "Main.ParamFloat: customized to 3.5, default changed from 1.234 to 2.5" {
+			t.Fatalf("%s: unexpected conflict %s", name, s)
+		}
+
+		bb, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != expected[name] {
+			t.Fatalf("%s: unexpected content\n%s", name, bb)
+		}
+
+		// Caricato sui nuovi default: personalizzazioni mantenute, il resto aggiornato.
+		data := newDefaults
+		data.Users = append([]settingsUsersItem(nil), newDefaults.Users...)
+		_, err = LoadFile(filename, &data, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := newDefaults
+		want.Main.ParamBool = false
+		want.Main.ParamFloat = 3.5
+		if !reflect.DeepEqual(data, want) {
+			t.Fatalf("%s: unexpected values %+v", name, data)
+		}
+	}
+
+	_, err := Upgrade(oldDefaults, newDefaults, filepath.Join(dir, "missing.yaml"))
+	if err == nil {
+		t.Fatal("missing file not detected")
+	}
+}
@@ -0,0 +1,339 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Interfaccia opzionale delle struct di configurazione (anche annidate)
+// per la validazione personalizzata dei valori.
+type Validator interface {
+	Validate() error
+}
+
+// Violazione di una regola di validazione.
+type ValidationError struct {
+	Path string // Percorso del campo, ad es. "main.paramSub.paramArray[2]".
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Elenco delle violazioni riscontrate durante la validazione.
+type ValidationErrors []*ValidationError
+
+func (ee ValidationErrors) Error() string {
+	msgs := make([]string, len(ee))
+	for i, e := range ee {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Valida la configurazione, ritornando tutte le violazioni riscontrate come ValidationErrors.
+//   - cfg: struttura (o PUNTATORE a struttura) configurazione da validare.
+//
+// Le regole sono date dal metodo Validate() (vedi Validator) di qualsiasi struct annidata
+// e dal tag `validate:"..."` dei campi, con i vincoli separati da virgola:
+//   - required: il valore non deve essere vuoto (zero, stringa, slice o map vuota, puntatore nil);
+//   - min=N, max=N: valore minimo o massimo per i numeri, lunghezza per stringhe, slice e map;
+//   - oneof=a b c: il valore deve essere uno di quelli elencati, separati da spazio;
+//   - url: la stringa, se non vuota, deve essere un URL assoluto;
+//   - regex=EXPR: la stringa, se non vuota, deve corrispondere all'espressione;
+//     deve essere l'ultimo vincolo, poiché può contenere virgole;
+//   - dive: i vincoli successivi sono applicati ai singoli elementi di slice, array e map.
+func Validate(cfg interface{}) error {
+	var errs ValidationErrors
+
+	validateValue(reflect.ValueOf(cfg), "", &errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateValue(v reflect.Value, path string, errs *ValidationErrors) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			validateValue(v.Elem(), path, errs)
+		}
+
+	case reflect.Struct:
+		validateStruct(v, path, errs)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), joinPath(path, fmt.Sprint(iter.Key().Interface())), errs)
+		}
+	}
+}
+
+func validateStruct(v reflect.Value, path string, errs *ValidationErrors) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinPath(path, lowerFirst(field.Name))
+		}
+
+		if tag, ok := field.Tag.Lookup("validate"); ok {
+			err := validateTag(fv, fieldPath, tag, errs)
+			if err != nil {
+				// Tag non valido: errore di programmazione, segnalato come violazione.
+				*errs = append(*errs, &ValidationError{Path: fieldPath, Err: err})
+			}
+		}
+
+		validateValue(fv, fieldPath, errs)
+	}
+
+	// Validazione personalizzata.
+	var validator Validator
+	if v.CanAddr() {
+		validator, _ = v.Addr().Interface().(Validator)
+	} else {
+		validator, _ = v.Interface().(Validator)
+	}
+	if validator == nil {
+		return
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return
+	}
+
+	var nested ValidationErrors
+	if errors.As(err, &nested) {
+		for _, e := range nested {
+			*errs = append(*errs, &ValidationError{Path: joinPath(path, e.Path), Err: e.Err})
+		}
+		return
+	}
+
+	*errs = append(*errs, &ValidationError{Path: path, Err: err})
+}
+
+// Applica i vincoli del tag al valore.
+func validateTag(v reflect.Value, path string, tag string, errs *ValidationErrors) error {
+	rules := splitRules(tag)
+
+	for i, rule := range rules {
+		if rule == "dive" {
+			return validateDive(v, path, strings.Join(rules[i+1:], ","), errs)
+		}
+
+		name, arg, _ := strings.Cut(rule, "=")
+
+		err := checkRule(v, name, arg)
+		if errors.Is(err, errInvalidRule) {
+			return err
+		}
+		if err != nil {
+			*errs = append(*errs, &ValidationError{Path: path, Err: err})
+		}
+	}
+
+	return nil
+}
+
+// Applica i vincoli agli elementi di slice, array e map.
+func validateDive(v reflect.Value, path string, tag string, errs *ValidationErrors) error {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			err := validateTag(v.Index(i), fmt.Sprintf("%s[%d]", path, i), tag, errs)
+			if err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			err := validateTag(iter.Value(), joinPath(path, fmt.Sprint(iter.Key().Interface())), tag, errs)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("%w: dive on %s", errInvalidRule, v.Kind())
+	}
+
+	return nil
+}
+
+var errInvalidRule = errors.New("invalid validation rule")
+
+// Verifica un singolo vincolo.
+func checkRule(v reflect.Value, name, arg string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if name == "required" {
+				return errors.New("is required")
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "required":
+		if v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+			return errors.New("is required")
+		}
+
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s=%s", errInvalidRule, name, arg)
+		}
+
+		n, isLen, ok := measure(v)
+		if !ok {
+			return fmt.Errorf("%w: %s on %s", errInvalidRule, name, v.Kind())
+		}
+
+		what := "must be"
+		if isLen {
+			what = "length must be"
+		}
+		if name == "min" && n < limit {
+			return fmt.Errorf("%s >= %s", what, arg)
+		}
+		if name == "max" && n > limit {
+			return fmt.Errorf("%s <= %s", what, arg)
+		}
+
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(arg) {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s]", strings.Join(strings.Fields(arg), ", "))
+
+	case "url":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("%w: url on %s", errInvalidRule, v.Kind())
+		}
+		if v.String() == "" {
+			return nil
+		}
+		u, err := url.Parse(v.String())
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+			return errors.New("must be a valid absolute URL")
+		}
+
+	case "regex":
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("%w: regex on %s", errInvalidRule, v.Kind())
+		}
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return fmt.Errorf("%w: regex=%s: %s", errInvalidRule, arg, err)
+		}
+		if v.String() != "" && !re.MatchString(v.String()) {
+			return fmt.Errorf("must match %s", arg)
+		}
+
+	default:
+		return fmt.Errorf("%w: %s", errInvalidRule, name)
+	}
+
+	return nil
+}
+
+// Ritorna il valore numerico, o la lunghezza per stringhe, slice e map.
+func measure(v reflect.Value) (n float64, isLen bool, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	}
+
+	return 0, false, false
+}
+
+// Separa i vincoli di un tag; "regex=" consuma il resto del tag.
+func splitRules(tag string) []string {
+	var rules []string
+
+	for tag != "" {
+		if strings.HasPrefix(tag, "regex=") {
+			rules = append(rules, tag)
+			break
+		}
+
+		rule, rest, _ := strings.Cut(tag, ",")
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+		tag = strings.TrimLeft(rest, " ")
+	}
+
+	return rules
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if key == "" {
+		return path
+	}
+	if strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+type validateSettings struct {
+	Main validateMain
+}
+
+type validateMain struct {
+	ParamSub    validateSub
+	ParamString string `validate:"required,oneof=debug info error"`
+	ParamInt    int    `validate:"min=1,max=10"`
+	ParamURL    string `validate:"url"`
+	ParamName   string `validate:"regex=^[a-z]{1,3}$"`
+}
+
+type validateSub struct {
+	ParamArray []int `validate:"max=3,dive,min=0"`
+}
+
+func (s validateSub) Validate() error {
+	if len(s.ParamArray) > 0 && s.ParamArray[0] == 99 {
+		return errors.New("first item cannot be 99")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	data := validateSettings{
+		Main: validateMain{
+			ParamSub:    validateSub{ParamArray: []int{1, 2, 3}},
+			ParamString: "info",
+			ParamInt:    5,
+			ParamURL:    "https://example.com/path",
+			ParamName:   "abc",
+		},
+	}
+
+	err := Validate(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data.Main.ParamSub.ParamArray = []int{99, 1, -1, 4}
+	data.Main.ParamString = ""
+	data.Main.ParamInt = 11
+	data.Main.ParamURL = "example"
+	data.Main.ParamName = "abcd"
+
+	err = Validate(&data)
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	want := []string{
+		"main.paramSub.paramArray: length must be <= 3",
+		"main.paramSub.paramArray[2]: must be >= 0",
+		"main.paramSub: first item cannot be 99",
+		"main.paramString: is required",
+		"main.paramString: must be one of [debug, info, error]",
+		"main.paramInt: must be <= 10",
+		"main.paramURL: must be a valid absolute URL",
+		"main.paramName: must match ^[a-z]{1,3}$",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors:\n%s", len(errs), err)
+	}
+	for i, e := range errs {
+		if e.Error() != want[i] {
+			t.Fatalf("got %q, want %q", e.Error(), want[i])
+		}
+	}
+}
+
+func TestLoaderValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"app.yaml": "main:\n  paramString: info\n  paramInt: 5\n  paramURL: ${VALTEST_HOST}/path\n  paramName: abc\n",
+	})
+	filename := filepath.Join(dir, "app.yaml")
+
+	t.Setenv("VALTEST_HOST", "https://example.com")
+
+	// LoadFile non valida: i valori non sono ancora interpolati.
+	var data validateSettings
+	_, err := LoadFile(filename, &data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Validate(&data) == nil {
+		t.Fatal("expected validation error on raw values")
+	}
+
+	// Il Loader valida i valori interpolati.
+	data = validateSettings{}
+	_, err = NewLoader(FileSource(filename, true)).Load(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Main.ParamURL != "https://example.com/path" {
+		t.Fatalf("unexpected value %s", data.Main.ParamURL)
+	}
+}
@@ -0,0 +1,209 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Intervallo di controllo delle modifiche ai file osservati.
+var WatchInterval = time.Second
+
+// Callback di notifica del ricaricamento della configurazione.
+//   - cfg: PUNTATORE alla nuova configurazione, nil in caso di errore.
+//   - err: errore di caricamento; in tal caso la configurazione corrente resta invariata.
+type WatchFunc func(cfg interface{}, err error)
+
+// Watcher osserva i file di configurazione, ricaricandola ad ogni modifica.
+// Ogni ricaricamento avviene su una nuova struttura, che sostituisce atomicamente
+// la corrente solo se il caricamento va a buon fine: le strutture ritornate da Config()
+// non vengono quindi mai modificate e possono essere lette in concorrenza.
+type Watcher struct {
+	base     interface{} // Copia della configurazione di partenza.
+	load     func(cfg interface{}) (filenames []string, err error)
+	onChange WatchFunc
+
+	current atomic.Value
+	files   map[string]os.FileInfo
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Carica la configurazione da file e la ricarica ad ogni modifica del file o dei file da esso inclusi,
+// compresi i salvataggi tramite rename effettuati da molti editor.
+//   - filename: vedi LoadFile; il file deve esistere.
+//   - cfg: PUNTATORE a struttura configurazione con i valori di partenza (ad es. i default);
+//     non viene modificata, la configurazione caricata si ottiene tramite Watcher.Config().
+//   - onChange: (opzionale) callback invocata ad ogni ricaricamento.
+//
+// Come LoadFile non valida la configurazione caricata: per ricaricamenti interpolati,
+// con secret risolti e validati usare Loader.Watch.
+func Watch(filename string, cfg interface{}, onChange WatchFunc) (*Watcher, error) {
+	load := func(cfg interface{}) ([]string, error) {
+		loadedFilename, included, err := loadFileIncludes(filename, cfg, true)
+		if err != nil {
+			return nil, err
+		}
+		return append(included, loadedFilename), nil
+	}
+
+	return newWatcher(cfg, load, onChange)
+}
+
+// Come Watch, ma applica ad ogni ricaricamento tutte le sorgenti del loader,
+// osservando i file da esse caricati e le directory drop-in (vedi MultiFileSource),
+// in cui l'aggiunta o la rimozione di un file provoca il ricaricamento.
+func (l *Loader) Watch(cfg interface{}, onChange WatchFunc) (*Watcher, error) {
+	load := func(cfg interface{}) ([]string, error) {
+		results, err := l.Load(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		var filenames []string
+		for _, result := range results {
+			filenames = append(filenames, result.Dirs...)
+
+			switch {
+			case result.Status != SourceLoaded:
+			case len(result.Files) > 0:
+				filenames = append(filenames, result.Files...)
+			case fileExists(result.Origin):
+				filenames = append(filenames, result.Origin)
+			}
+		}
+		return filenames, nil
+	}
+
+	return newWatcher(cfg, load, onChange)
+}
+
+func newWatcher(cfg interface{}, load func(cfg interface{}) ([]string, error), onChange WatchFunc) (*Watcher, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, errors.New("config data must be a pointer")
+	}
+
+	base := reflect.New(v.Elem().Type()).Interface()
+	err := cloneData(cfg, base)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		base:     base,
+		load:     load,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	err = w.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// Ritorna il PUNTATORE alla configurazione corrente, da non modificare.
+func (w *Watcher) Config() interface{} {
+	return w.current.Load()
+}
+
+// Termina l'osservazione dei file.
+func (w *Watcher) Close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+
+		case <-ticker.C:
+			if !w.changed() {
+				continue
+			}
+
+			err := w.reload()
+			if w.onChange == nil {
+				continue
+			}
+			if err != nil {
+				w.onChange(nil, err)
+			} else {
+				w.onChange(w.Config(), nil)
+			}
+		}
+	}
+}
+
+// Carica la configurazione in una nuova struttura e, se non ci sono errori, la rende corrente.
+func (w *Watcher) reload() error {
+	cfg := reflect.New(reflect.TypeOf(w.base).Elem()).Interface()
+
+	err := cloneData(w.base, cfg)
+	if err != nil {
+		return err
+	}
+
+	filenames, err := w.load(cfg)
+
+	// Aggiorna comunque lo stato dei file, per non ritentare finché non vengono nuovamente modificati.
+	files := make(map[string]os.FileInfo, len(filenames))
+	for _, filename := range filenames {
+		info, _ := os.Stat(filename)
+		files[filename] = info
+	}
+	if err == nil || w.files == nil {
+		w.files = files
+	} else {
+		for filename := range w.files {
+			info, _ := os.Stat(filename)
+			w.files[filename] = info
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	w.current.Store(cfg)
+
+	return nil
+}
+
+// Ritorna true se almeno uno dei file o delle directory osservati è stato modificato.
+// I file momentaneamente assenti (ad es. durante un salvataggio tramite rename) vengono ignorati.
+func (w *Watcher) changed() bool {
+	for filename, prev := range w.files {
+		info, err := os.Stat(filename)
+		if err != nil {
+			continue
+		}
+
+		if prev == nil || !os.SameFile(prev, info) ||
+			!info.ModTime().Equal(prev.ModTime()) || info.Size() != prev.Size() {
+			return true
+		}
+	}
+
+	return false
+}